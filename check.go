@@ -0,0 +1,120 @@
+package notionblog
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Problem is a problem of config or database found by Check
+type Problem struct {
+	// Database is the database id in config, empty if the problem is not about a database
+	Database string
+	Message  string
+	// Fix is the suggested fix
+	Fix string
+}
+
+// Check validates the config, the database views and the database schemas.
+// It reports every problem found, nothing will be downloaded or written.
+func (s *Syncer) Check() []*Problem {
+	// the problems of dirs, config and target found by New
+	problems := append([]*Problem{}, s.problems...)
+	add := func(database, message, fix string) {
+		problems = append(problems, &Problem{Database: database, Message: message, Fix: fix})
+	}
+
+	// config
+	if version := s.config.GetInt("version"); version != 1 {
+		add("", fmt.Sprintf("unsupported config version %d", version), "Set version to 1 in config.yml")
+	}
+	if _, err := time.LoadLocation(s.config.GetString("user.timezone")); err != nil {
+		add("", fmt.Sprintf("invalid user.timezone: %v", err), "Use a name in the tz database, e.g. Etc/UTC or Asia/Shanghai")
+	}
+
+	postDatabases := s.config.GetStringSlice("database.post")
+	if len(postDatabases) == 0 {
+		add("", "no database in database.post", "Add a database id in format pageID+ViewID to database.post, or run nb init with the database url")
+		return problems
+	}
+
+	var dbs []*database
+	var ids []string
+	for _, postDb := range postDatabases {
+		db, err := parseDatabaseID(postDb)
+		if err != nil {
+			add(postDb, err.Error(), "Run nb init with the database url to get the right id")
+			continue
+		}
+		dbs = append(dbs, db)
+		ids = append(ids, postDb)
+	}
+	if len(dbs) == 0 {
+		return problems
+	}
+
+	// views
+	s.initClient()
+	s.initUser()
+
+	pageIDs := make([]string, len(dbs))
+	for i := range dbs {
+		pageIDs[i] = dbs[i].pageID
+	}
+	resp, err := s.client.GetBlockRecords(pageIDs)
+	if err != nil {
+		add("", fmt.Sprintf("cannot read databases from notion: %v", err), "Check your network and token_v2")
+		return problems
+	}
+	if len(resp.Results) != len(dbs) {
+		add("", "unknown error when get collectionID", "Try again later")
+		return problems
+	}
+
+	for i, db := range dbs {
+		if err := checkCollectionView(db, resp.Results[i].Block); err != nil {
+			add(ids[i], err.Error(), "Open the database as a full page in notion, check token_v2 can read it, and copy the url with ?v= to nb init")
+			continue
+		}
+
+		// schemas
+		if s.target == nil {
+			// they can't be checked without target
+			continue
+		}
+		_, collection, err := s.queryDatabase(db)
+		if err != nil {
+			add(ids[i], err.Error(), "Check your network and token_v2")
+			continue
+		}
+		_, err = s.buildFrontMatter(collection.Schema)
+		if errs, ok := err.(schemaError); ok {
+			for _, err := range errs {
+				add(ids[i], err.Error(), err.fix())
+			}
+		} else if err != nil {
+			add(ids[i], err.Error(), "")
+		}
+	}
+
+	return problems
+}
+
+// PrintProblems prints the problems found by Check
+func PrintProblems(w io.Writer, problems []*Problem) {
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "No problem found.")
+		return
+	}
+
+	for _, problem := range problems {
+		if problem.Database != "" {
+			fmt.Fprintf(w, "[%s] ", problem.Database)
+		}
+		fmt.Fprintln(w, problem.Message)
+		if problem.Fix != "" {
+			fmt.Fprintln(w, "    Fix:", problem.Fix)
+		}
+	}
+	fmt.Fprintf(w, "%d problems found.\n", len(problems))
+}
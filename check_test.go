@@ -0,0 +1,26 @@
+package notionblog
+
+import (
+	"github.com/magiconair/properties/assert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckReportsSetupProblems(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nb-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := New(Options{RootDir: dir, Target: "nope", DryRun: true, Check: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := s.Check()
+	assert.Equal(t, len(problems), 2)
+	assert.Equal(t, strings.HasPrefix(problems[0].Message, "unknown target nope"), true)
+	assert.Equal(t, problems[1].Message, "no database in database.post")
+}
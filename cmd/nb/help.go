@@ -0,0 +1,44 @@
+package main
+
+// the details of commands, printed by nb <command> -h
+var commandHelps = map[string]string{
+	"sync": `Sync renders the published pages of the databases in config.yml to the blog.
+Only the edited, newly published and unpublished pages are rendered again,
+unless converter.force is set or NB is upgraded.
+A post dated in the future is published by the first sync after its date.
+
+-dry-run (the same as nb plan) prints which markdown files a sync would
+create, rewrite or delete and which images it would download, without
+touching the blog.
+
+-offline renders all pages again from the cache and the tree.yml of the last
+sync, without a token or network access, e.g. after changing the converter
+options. The images never downloaded can't be fetched offline.
+
+-report writes the processed databases, the downloaded, rendered and
+unpublished pages, the deleted files, the fetched images, the warnings and the
+time of each phase as JSON.
+
+A failed page or image doesn't stop the sync, the page keeps its last output
+and is rendered again by the next sync. The exit code is 0 for success, 3 if
+some pages or images failed or there are warnings, 1 if the sync stopped or
+every page it tried failed, and 2 for invalid arguments.`,
+	"watch": `Watch checks notion every interval, and syncs the edited, newly published and
+unpublished pages. The scheduled posts are published on time.
+The -exec command runs in the root of the blog after each sync that wrote or
+deleted files of the blog, so a page that keeps failing doesn't run it again
+and again.`,
+	"init": `Init creates _notion/config.yml for the database. Open the database as a full
+page in notion and copy its url, which must contain the ?v= view parameter.
+The token is read from -token or the NOTION_TOKEN environment variable, and
+only the one passed by -token is written to the config file.`,
+	"check": `Check validates the root, config.yml, the target and the columns of every
+database, and prints each problem with a suggested fix. The exit code is 1 if
+any problem is found.`,
+	"render": `Render prints the markdown of a single page, front matter included. The page
+is read from the cache first.`,
+	"serve": `Serve starts a preview server. Any page can be visited at /page/<page id>,
+including the drafts whose status isn't Published, which are listed on the
+index with the front matter of their database. Links between pages go to
+their previews.`,
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	notionblog "github.com/ImSingee/NotionBlog"
+)
+
+// exit codes, 2 is used by flag for invalid arguments
+const (
+	exitFailure = 1
+	exitPartial = 3
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string)
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"sync", "Sync the notion databases to the blog (default)", runSync},
+		{"plan", "Print what sync will do without touching the blog, same as sync -dry-run", runPlan},
+		{"watch", "Poll notion and sync the changed pages continuously", runWatch},
+		{"init", "Create _notion/config.yml from a notion database url", runInit},
+		{"check", "Validate the config and the database schemas, report all problems", runCheck},
+		{"render", "Print the markdown of a single page", runRender},
+		{"serve", "Start a local server to preview pages as html", runServe},
+	}
+}
+
+// newFlagSet returns a flag set with the common flags, the options will be filled after parsing
+func newFlagSet(name string) (*flag.FlagSet, *notionblog.Options) {
+	opts := &notionblog.Options{}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&opts.RootDir, "root", ".", "The root of your blog")
+	fs.StringVar(&opts.CacheDir, "cache", "", "The cache dir, default to the cache dir in root/source/_notion or root/_notion")
+	fs.StringVar(&opts.Target, "target", "", "The static site generator of your blog, default to the target in config.yml or hexo")
+	fs.Usage = func() { printUsage(fs, name, "") }
+
+	return fs, opts
+}
+
+// print the usage line, the details and the flags of command
+func printUsage(fs *flag.FlagSet, name string, args string) {
+	fmt.Fprintf(fs.Output(), "Usage: nb %s [flags]%s\n\n", name, args)
+	if help := commandHelps[name]; help != "" {
+		fmt.Fprintln(fs.Output(), help)
+		fmt.Fprintln(fs.Output())
+	}
+	fmt.Fprintln(fs.Output(), "Flags:")
+	fs.PrintDefaults()
+}
+
+func newSyncer(opts *notionblog.Options) *notionblog.Syncer {
+	s, err := notionblog.New(*opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s
+}
+
+func runSync(args []string) {
+	fs, opts := newFlagSet("sync")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the sync plan without touching the blog")
+	fs.BoolVar(&opts.Offline, "offline", false, "Render all pages from cache and the last tree.yml without connecting to notion")
+	reportPath := fs.String("report", "", "Write a JSON report of the run to the path, - for stdout")
+	_ = fs.Parse(args)
+
+	s := newSyncer(opts)
+
+	var err error
+	if opts.DryRun {
+		err = s.DryRun(os.Stdout)
+	} else {
+		err = s.Sync()
+	}
+
+	report := s.Report()
+	if *reportPath != "" {
+		if reportErr := writeReport(report, *reportPath); reportErr != nil {
+			log.Println("Warning: cannot write report.", reportErr)
+		}
+	}
+
+	switch report.Status {
+	case notionblog.StatusFailure:
+		if err != nil {
+			log.Println(err)
+		}
+		log.Println("Sync failed.")
+		os.Exit(exitFailure)
+	case notionblog.StatusPartial:
+		log.Printf("Sync finished with %d failures and %d warnings.", len(report.Failures), len(report.Warnings))
+		os.Exit(exitPartial)
+	}
+}
+
+func writeReport(report *notionblog.Report, reportPath string) error {
+	if reportPath == "-" {
+		return report.WriteJSON(os.Stdout)
+	}
+
+	f, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return report.WriteJSON(f)
+}
+
+func runPlan(args []string) {
+	runSync(append([]string{"-dry-run"}, args...))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: nb [command] [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run nb [command] -h to see the details and the flags of command.")
+}
+
+func main() {
+	name := "sync"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.run(args)
+			return
+		}
+	}
+
+	if name == "help" {
+		usage()
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Unknown command:", name)
+	usage()
+	os.Exit(2)
+}
+
+func runWatch(args []string) {
+	fs, opts := newFlagSet("watch")
+	interval := fs.Duration("interval", 30*time.Second, "The time between two checks")
+	command := fs.String("exec", "", "The command to run after each sync that changed the blog, e.g. \"hexo generate\"")
+	_ = fs.Parse(args)
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "The interval must be positive.")
+		os.Exit(2)
+	}
+
+	s := newSyncer(opts)
+
+	watchOpts := notionblog.WatchOptions{
+		Interval: *interval,
+	}
+	if *command != "" {
+		watchOpts.AfterSync = func() error {
+			log.Println("Run:", *command)
+			cmd := exec.Command("sh", "-c", *command)
+			cmd.Dir = s.RootDir()
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	err := s.Watch(ctx, watchOpts)
+	if err != nil && err != context.Canceled {
+		log.Fatal(err)
+	}
+}
+
+func runInit(args []string) {
+	fs, opts := newFlagSet("init")
+	initOpts := notionblog.InitOptions{}
+	fs.StringVar(&initOpts.Token, "token", "", "Your notion's token_v2, default to the NOTION_TOKEN environment variable")
+	fs.BoolVar(&initOpts.Overwrite, "force", false, "Overwrite the existing config file")
+	fs.Usage = func() { printUsage(fs, "init", " <notion database url>") }
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	initOpts.DatabaseURL = fs.Arg(0)
+
+	s := newSyncer(opts)
+	if err := s.Init(initOpts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runCheck(args []string) {
+	fs, opts := newFlagSet("check")
+	_ = fs.Parse(args)
+
+	// check never changes the blog, and reports the problems of dirs, config and target too
+	opts.DryRun = true
+	opts.Check = true
+	s := newSyncer(opts)
+
+	problems := s.Check()
+	notionblog.PrintProblems(os.Stdout, problems)
+	if len(problems) != 0 {
+		os.Exit(1)
+	}
+}
+
+func runRender(args []string) {
+	fs, opts := newFlagSet("render")
+	noImages := fs.Bool("no-images", false, "Do not download the images of the page")
+	fs.BoolVar(&opts.Offline, "offline", false, "Render the page from cache without connecting to notion")
+	fs.Usage = func() { printUsage(fs, "render", " <page id or notion url>") }
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	s := newSyncer(opts)
+	data, err := s.RenderPage(fs.Arg(0), !*noImages)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, _ = os.Stdout.Write(data)
+}
+
+func runServe(args []string) {
+	fs, opts := newFlagSet("serve")
+	addr := fs.String("addr", "localhost:4000", "The address to listen on")
+	fs.BoolVar(&opts.Offline, "offline", false, "Render the pages from cache without connecting to notion")
+	_ = fs.Parse(args)
+
+	s := newSyncer(opts)
+	handler, err := s.PreviewHandler()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Preview server is listening on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
@@ -1,42 +1,70 @@
-package main
+package notionblog
 
 import (
-	"github.com/spf13/viper"
+	"fmt"
 	"log"
+
+	"github.com/spf13/viper"
 )
 
-func setDefaultConfig() {
-	viper.SetDefault("version", 1)
-	viper.SetDefault("user.locale", "en")
-	viper.SetDefault("user.timezone", "Etc/UTC")
-	viper.SetDefault("render.checkbox", false)
+func setDefaultConfig(config *viper.Viper) {
+	config.SetDefault("version", 1)
+	config.SetDefault("target", "hexo")
+	config.SetDefault("user.locale", "en")
+	config.SetDefault("user.timezone", "Etc/UTC")
+	config.SetDefault("render.checkbox", false)
+	config.SetDefault("frontmatter.order", []string{"title", "date", "updated", "categories", "tags", "description", "url"})
+	config.SetDefault("hugo.section", "posts")
+	config.SetDefault("hugo.front_matter", "toml")
+	config.SetDefault("jekyll.collection", "pages")
+	config.SetDefault("docusaurus.route_base_path", "docs")
+	config.SetDefault("zola.section", "blog")
+	config.SetDefault("html.title", "Blog")
+	config.SetDefault("html.output", "public")
+	config.SetDefault("html.templates", "templates")
+	config.SetDefault("json.output", "api")
+	config.SetDefault("json.assets_url", "/assets")
+	config.SetDefault("feed.enable", false)
+	config.SetDefault("feed.title", "Blog")
+	config.SetDefault("feed.content", false)
+	config.SetDefault("feed.limit", 20)
+	config.SetDefault("feed.dir", "feeds")
+	config.SetDefault("sitemap.enable", false)
+	config.SetDefault("epub.output", "epub")
+	config.SetDefault("epub.title", "Book")
+	config.SetDefault("epub.author", "")
+	config.SetDefault("epub.language", "en")
 }
 
-func loadConfig() {
-	setDefaultConfig()
+func (s *Syncer) loadConfig() error {
+	s.config = viper.New()
+	setDefaultConfig(s.config)
 
-	viper.SetConfigName("config")
-	viper.AddConfigPath(notionDir)
+	s.config.SetConfigName("config")
+	s.config.AddConfigPath(s.notionDir)
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := s.config.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; ignore
 			log.Println("Cannot find config file.")
 		} else {
-			log.Fatal("Can not open or parse config.yml: ", err)
+			return fmt.Errorf("can not open or parse config.yml: %v", err)
 		}
 	}
 
-	_ = viper.BindEnv("token_v2", "NOTION_TOKEN")
-	_ = viper.BindEnv("converter.force", "NOTION_CONVERTER_FORCE")
-	_ = viper.BindEnv("render.checkbox", "NOTION_RENDER_CHECKBOX")
-	_ = viper.BindEnv("database.post", "NOTION_DATABASE_POST")
-	_ = viper.BindEnv("user.locale", "NOTION_USER_LOCALE")
-	_ = viper.BindEnv("user.timezone", "NOTION_USER_TIMEZONE")
+	_ = s.config.BindEnv("token_v2", "NOTION_TOKEN")
+	_ = s.config.BindEnv("target", "NOTION_TARGET")
+	_ = s.config.BindEnv("converter.force", "NOTION_CONVERTER_FORCE")
+	_ = s.config.BindEnv("render.checkbox", "NOTION_RENDER_CHECKBOX")
+	_ = s.config.BindEnv("database.post", "NOTION_DATABASE_POST")
+	_ = s.config.BindEnv("user.locale", "NOTION_USER_LOCALE")
+	_ = s.config.BindEnv("user.timezone", "NOTION_USER_TIMEZONE")
+
+	return nil
 }
 
-func getAlias(key string, fallback string) string {
-	v := viper.GetString("alias." + key)
+func (s *Syncer) getAlias(key string, fallback string) string {
+	v := s.config.GetString("alias." + key)
 	if v == "" {
 		return fallback
 	}
@@ -1,6 +1,7 @@
-package main
+package notionblog
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -10,36 +11,77 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/kjk/notionapi"
-	"github.com/spf13/viper"
+	"github.com/kjk/notionapi/caching_downloader"
 )
 
-var imageWg sync.WaitGroup
 var imageClient *http.Client
 
 func init() {
 	imageClient = &http.Client{}
 }
 
-func downloadImage(source string, block *notionapi.Block) string {
+// Image is an image used by a rendered page, it will be downloaded in the Write phase
+type Image struct {
+	// PageID is the page which uses the image
+	PageID string
+	// Source is the url of image in notion
+	Source string
+	// BlockID is the id of image block
+	BlockID string
+	// Filename is the path the image will be saved to
+	Filename string
+	// URL is the url of image used in markdown
+	URL string
+	// Remote is an image not hosted by notion, it's downloaded by the caching downloader without the token
+	Remote bool
+}
+
+// returns the url used in markdown, and the image need to be downloaded (nil if the image need not to be downloaded)
+func (s *Syncer) parseImage(source string, page *Page, blockID string) (string, *Image) {
 	imageUrl, err := url.Parse(source)
 	if err != nil {
-		return source
+		return source, nil
 	}
 
-	if !strings.HasSuffix(imageUrl.Host, "amazonaws.com") {
-		return source
-	}
-	if !strings.HasPrefix(imageUrl.Path, "/secure.notion-static.com") {
-		return source
+	if !strings.HasSuffix(imageUrl.Host, "amazonaws.com") || !strings.HasPrefix(imageUrl.Path, "/secure.notion-static.com") {
+		return s.parseRemoteImage(source, imageUrl, page, blockID)
 	}
 
 	downloadFilename := imageUrl.Path[len("/secure.notion-static.com"):]
+	filename, url := s.target.Image(s.layout, page, downloadFilename)
+
+	return url, &Image{
+		PageID:   page.ID,
+		Source:   source,
+		BlockID:  blockID,
+		Filename: filename,
+		URL:      url,
+	}
+}
 
-	imageWg.Add(1)
-	go downloadImageProcess(source, downloadFilename, block)
+// the remote images are only downloaded for ImageEmbedder, they are named by the sha1 of url
+func (s *Syncer) parseRemoteImage(source string, imageUrl *url.URL, page *Page, blockID string) (string, *Image) {
+	if embedder, ok := s.target.(ImageEmbedder); !ok || !embedder.EmbedImages() {
+		return source, nil
+	}
+	if imageUrl.Scheme != "http" && imageUrl.Scheme != "https" {
+		return source, nil
+	}
 
-	return "/images" + downloadFilename
+	ext := strings.ToLower(path.Ext(imageUrl.Path))
+	if len(ext) > 5 {
+		ext = ""
+	}
+	filename, url := s.target.Image(s.layout, page, "/remote/"+caching_downloader.Sha1OfURL(source)+ext)
+
+	return url, &Image{
+		PageID:   page.ID,
+		Source:   source,
+		BlockID:  blockID,
+		Filename: filename,
+		URL:      url,
+		Remote:   true,
+	}
 }
 
 func createFile(filename string) (*os.File, error) {
@@ -55,44 +97,81 @@ func createFile(filename string) (*os.File, error) {
 	return imageFile, err
 }
 
-func downloadImageProcess(imageUrl, filename string, block *notionapi.Block) {
-	defer imageWg.Done()
-
-	url := "https://www.notion.so/image/" + url.QueryEscape(imageUrl) + "?table=block&id=" + block.ID
+// request the image from notion, the caller should close the body of response
+func (s *Syncer) fetchImage(image *Image) (*http.Response, error) {
+	url := "https://www.notion.so/image/" + url.QueryEscape(image.Source) + "?table=block&id=" + image.BlockID
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		log.Fatal("Cannot download image:", imageUrl, ". Err:", err)
-		return
+		return nil, fmt.Errorf("cannot download image %s: %v", image.Source, err)
 	}
-	req.Header.Set("Cookie", "token_v2="+viper.GetString("token_v2"))
+	req.Header.Set("Cookie", "token_v2="+s.config.GetString("token_v2"))
 
 	resp, err := imageClient.Do(req)
 	if err != nil {
-		log.Fatal("Cannot download image:", imageUrl, ". Err:", err)
-		return
+		return nil, fmt.Errorf("cannot download image %s: %v", image.Source, err)
 	}
-	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		log.Fatal("Cannot download image:", imageUrl, ". Err: StatusCode=", resp.StatusCode)
-		return
+		resp.Body.Close()
+		return nil, fmt.Errorf("cannot download image %s: StatusCode=%d", image.Source, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// download the remote image by the caching downloader, it's kept in the files dir of cache
+func (s *Syncer) downloadRemoteImage(image *Image) error {
+	if s.imageDownloader == nil {
+		return fmt.Errorf("cannot download image %s: no downloader", image.Source)
 	}
-	destinationFilename := path.Join(sourceDir, "images", filename)
-	imageFile, err := createFile(destinationFilename)
+	res, err := s.imageDownloader.DownloadFile(image.Source, image.BlockID)
 	if err != nil {
-		log.Fatal("Cannot save image", imageUrl, "to", destinationFilename, ". Err:", err)
-		return
+		return fmt.Errorf("cannot download image %s: %v", image.Source, err)
+	}
+	if err := save(image.Filename, res.Data); err != nil {
+		return fmt.Errorf("cannot save image %s to %s: %v", image.Source, image.Filename, err)
+	}
+	return nil
+}
+
+func (s *Syncer) downloadImage(image *Image) error {
+	if image.Remote {
+		return s.downloadRemoteImage(image)
+	}
+
+	resp, err := s.fetchImage(image)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	imageFile, err := createFile(image.Filename)
+	if err != nil {
+		return fmt.Errorf("cannot save image %s to %s: %v", image.Source, image.Filename, err)
 	}
 	defer imageFile.Close()
 	_, err = io.Copy(imageFile, resp.Body)
 	if err != nil {
-		log.Fatal("Cannot save image", imageUrl, "to", destinationFilename, ". Err:", err)
-		return
+		return fmt.Errorf("cannot save image %s to %s: %v", image.Source, image.Filename, err)
 	}
 
-	log.Println("Download image:", filename)
+	log.Println("Download image:", image.Filename)
+	return nil
 }
 
-func waitDownloadImage() {
-	imageWg.Wait()
+// download all images concurrently, the failed images will be recorded in report
+func (s *Syncer) downloadImages(images []*Image) {
+	var wg sync.WaitGroup
+
+	for _, image := range images {
+		wg.Add(1)
+		go func(image *Image) {
+			defer wg.Done()
+			if err := s.downloadImage(image); err != nil {
+				s.fail(image.PageID, image.Source, err)
+			} else {
+				s.report.add(&s.report.Images, image.Filename)
+			}
+		}(image)
+	}
+	wg.Wait()
 }
@@ -0,0 +1,30 @@
+package notionblog
+
+import (
+	"github.com/kjk/notionapi/caching_downloader"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestParseRemoteImage(t *testing.T) {
+	page := &Page{ID: "11112222-aaaa-bbbb-cccc-ddddeeeeffff"}
+	source := "https://example.com/a/b.PNG"
+
+	// linked by the targets for the web
+	s := &Syncer{target: hugoTarget{}, layout: &Layout{ImagesDir: "/blog/static/images", ImagesURL: "/images"}}
+	url, image := s.parseImage(source, page, "block")
+	assert.Equal(t, url, source)
+	assert.Equal(t, image == nil, true)
+
+	// embedded in books
+	s = &Syncer{target: epubTarget{}, layout: &Layout{ImagesDir: "/blog/epub/images", ImagesURL: "../images"}}
+	url, image = s.parseImage(source, page, "block")
+	name := "/remote/" + caching_downloader.Sha1OfURL(source) + ".png"
+	assert.Equal(t, url, "../images"+name)
+	assert.Equal(t, image.Filename, "/blog/epub/images"+name)
+	assert.Equal(t, image.Remote, true)
+
+	url, image = s.parseImage("data:image/png;base64,AAAA", page, "block")
+	assert.Equal(t, url, "data:image/png;base64,AAAA")
+	assert.Equal(t, image == nil, true)
+}
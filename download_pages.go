@@ -1,4 +1,4 @@
-package main
+package notionblog
 
 import (
 	"fmt"
@@ -47,53 +47,64 @@ func getPagesLatestVersion(d *caching_downloader.Downloader, ids []string) ([]in
 	return versions, nil
 }
 
-func downloadPagesOnDemand(downloader *caching_downloader.Downloader, pageIDs []string) ([]*updatedPage, error) {
+func (s *Syncer) downloadPagesOnDemand(pageIDs []string) ([]*updatedPage, error) {
 	pages := make([]*updatedPage, len(pageIDs))
 
-	latestVersions, err := getPagesLatestVersion(downloader, pageIDs)
+	latestVersions, err := getPagesLatestVersion(s.downloader, pageIDs)
 	if err != nil {
 		return nil, err
 	}
 
 	for i, pageID := range pageIDs {
-		page, err := downloader.ReadPageFromCache(pageID)
+		page, err := s.readCachedPage(pageID)
+		if err != nil {
+			// broken cache, download it again
+			s.warn("Warning: cannot read page", pageID, "from cache.", err)
+			page = nil
+		}
 		pages[i] = &updatedPage{
 			page:    page,
 			updated: false,
 		}
-		if err != nil {
-			return nil, err
-		}
 		if page == nil || latestVersions[i] > page.Root().Version {
 			log.Println("Download page:", pageID)
-			page, err = downloader.DownloadPage(pageID)
+			// the downloader remembers the version when it downloaded the page last time,
+			// tell it the latest one, otherwise it may return the page it downloaded before
+			s.downloader.IdToPageLatestVersion[notionapi.ToNoDashID(pageID)] = latestVersions[i]
+			downloadedPage, err := s.downloader.DownloadPage(pageID)
 
 			if err != nil {
-				return nil, err
+				// keep the cached version if there is one, the page will be downloaded again next time
+				s.fail(pageID, "", fmt.Errorf("cannot download page: %v", err))
+				continue
 			}
 
-			pages[i].page = page
+			s.pages[pageID] = downloadedPage
+			pages[i].page = downloadedPage
 			pages[i].updated = true
 		}
 	}
 
-	return pages, err
+	return pages, nil
 }
 
-func downloadPagesAndSubPagesOnDemand(downloader *caching_downloader.Downloader, pageIDs []string) ([]string, error) {
+func (s *Syncer) downloadPagesAndSubPagesOnDemand(pageIDs []string) ([]string, error) {
 	updatedPages := make([]string, 0)
 	downloaded := make(map[string]struct{}, 0)
 
 	toVisit := pageIDs
 	for len(toVisit) > 0 {
 		toDashIDs(toVisit)
-		pages, err := downloadPagesOnDemand(downloader, toVisit)
+		pages, err := s.downloadPagesOnDemand(toVisit)
 		if err != nil {
 			return nil, err
 		}
 		toVisit = toVisit[len(toVisit):]
 
 		for _, page := range pages {
+			if page.page == nil { // fail to download and not in cache
+				continue
+			}
 			downloaded[page.page.ID] = struct{}{}
 			if page.updated { // updated page
 				updatedPages = append(updatedPages, page.page.ID)
@@ -0,0 +1,135 @@
+package notionblog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kjk/notionapi/caching_downloader"
+)
+
+// dryRunCache reads pages from the cache dir but keeps all changes in memory
+type dryRunCache struct {
+	dir *caching_downloader.DirectoryCache
+
+	mu      sync.Mutex
+	files   map[string][]byte
+	removed map[string]struct{}
+}
+
+var _ caching_downloader.Cache = &dryRunCache{}
+
+func newDryRunCache(dir string) *dryRunCache {
+	return &dryRunCache{
+		dir:     &caching_downloader.DirectoryCache{Dir: dir},
+		files:   make(map[string][]byte),
+		removed: make(map[string]struct{}),
+	}
+}
+
+func (c *dryRunCache) ReadFile(name string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if data, ok := c.files[name]; ok {
+		return data, nil
+	}
+	if _, ok := c.removed[name]; ok {
+		return nil, os.ErrNotExist
+	}
+	return c.dir.ReadFile(name)
+}
+
+func (c *dryRunCache) WriteFile(name string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.files[name] = data
+	delete(c.removed, name)
+	return nil
+}
+
+func (c *dryRunCache) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.files, name)
+	c.removed[name] = struct{}{}
+}
+
+func (c *dryRunCache) GetPageIDs() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the cache dir may not exist in dry-run mode
+	dirIDs, _ := c.dir.GetPageIDs()
+
+	ids := make([]string, 0, len(dirIDs)+len(c.files))
+	seen := make(map[string]struct{}, len(dirIDs)+len(c.files))
+	for _, id := range dirIDs {
+		if _, ok := c.removed[id+".txt"]; !ok {
+			ids = append(ids, id)
+			seen[id] = struct{}{}
+		}
+	}
+	for name := range c.files {
+		id := strings.TrimSuffix(name, ".txt")
+		if _, ok := seen[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// PrintPlan prints what Write will do with the plan and rendered pages
+func PrintPlan(w io.Writer, plan *Plan, pages []*RenderedPage) {
+	created, rewritten, images := 0, 0, 0
+
+	for _, page := range pages {
+		if fileExists(page.Filename) {
+			rewritten++
+			fmt.Fprintln(w, "Rewrite:", page.Filename)
+		} else {
+			created++
+			fmt.Fprintln(w, "Create:", page.Filename)
+		}
+	}
+	for _, filename := range plan.DeleteFiles {
+		fmt.Fprintln(w, "Delete:", filename)
+	}
+	for _, page := range pages {
+		for _, image := range page.Images {
+			images++
+			fmt.Fprintln(w, "Download image:", image.Filename)
+		}
+	}
+
+	fmt.Fprintf(w, "%d files to create, %d files to rewrite, %d files to delete, %d images to download.\n",
+		created, rewritten, len(plan.DeleteFiles), images)
+}
+
+// DryRun runs Fetch, Plan and Render, then prints the plan to w without changing the blog
+// The Syncer should be created with Options.DryRun, otherwise the downloaded pages will be saved to cache
+func (s *Syncer) DryRun(w io.Writer) error {
+	if err := s.Fetch(); err != nil {
+		return s.finishReport(err)
+	}
+	plan, err := s.Plan()
+	if err != nil {
+		return s.finishReport(err)
+	}
+	pages, err := s.Render(plan)
+	if err != nil {
+		return s.finishReport(err)
+	}
+
+	PrintPlan(w, plan, pages)
+	return s.finishReport(nil)
+}
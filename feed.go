@@ -0,0 +1,314 @@
+package notionblog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+)
+
+// feedItem is a published post in feeds
+type feedItem struct {
+	ID          string
+	Title       string
+	URL         string
+	Description string
+	Content     string // the full html, empty if feed.content is false
+	Date        time.Time
+	Updated     time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Links   []*atomLink  `xml:"link"`
+	Updated string       `xml:"updated"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string    `xml:"title"`
+	ID        string    `xml:"id"`
+	Link      *atomLink `xml:"link"`
+	Published string    `xml:"published"`
+	Updated   string    `xml:"updated"`
+	Summary   *atomText `xml:"summary,omitempty"`
+	Content   *atomText `xml:"content,omitempty"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        *rssGUID `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description,omitempty"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded,omitempty"`
+}
+
+// feedTarget renders the content of posts in feeds, all links and images are absolute
+type feedTarget struct {
+	Target
+	siteURL string
+}
+
+func (feedTarget) FrontMatter(page *Page) (string, error) {
+	return "", nil
+}
+
+func (t feedTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	filename, url := t.Target.Image(layout, page, name)
+	if !strings.HasPrefix(url, "/") {
+		// the image is relative to the page
+		return filename, absoluteURL(t.siteURL, t.Target.URL(page)+url)
+	}
+	return filename, absoluteURL(t.siteURL, url)
+}
+
+func (t feedTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf("[%s](%s)", title, absoluteURL(t.siteURL, url))
+}
+
+func (feedTarget) Excerpt() string {
+	return ""
+}
+
+func (feedTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("[Gist %s/%s](https://gist.github.com/%s/%s)", user, id, user, id)
+}
+
+// join the url of site and the url of page
+func absoluteURL(siteURL string, url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+	return strings.TrimSuffix(siteURL, "/") + "/" + strings.TrimPrefix(url, "/")
+}
+
+// render the page to html for feeds
+func (s *Syncer) feedContent(page *notionapi.Page) (string, error) {
+	target := s.target
+	s.target = feedTarget{Target: target, siteURL: s.config.GetString("url")}
+	defer func() { s.target = target }()
+
+	data, _, err := s.pageToMarkdown(page)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := newMarkdownRenderer().Convert(data, &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// the items of the published posts in db, in the order of database view
+func (s *Syncer) feedItems(db *database) []*feedItem {
+	siteURL := s.config.GetString("url")
+	items := make([]*feedItem, 0, len(db.subpageIDs))
+	for _, pageID := range db.subpageIDs {
+		page, err := s.readCachedPage(pageID)
+		if err != nil || page == nil {
+			continue
+		}
+		p := s.newPage(page.Root(), page.Root().Title)
+
+		item := &feedItem{
+			ID:          pageID,
+			Title:       p.GetString("title"),
+			URL:         absoluteURL(siteURL, s.getUrlByPageID(pageID)),
+			Description: p.GetString("description"),
+			Date:        p.CreatedTime,
+			Updated:     p.LastEditedTime,
+		}
+		location := p.CreatedTime.Location()
+		if date, _, ok := parseDate(p.GetString("date"), location); ok {
+			item.Date = date
+		}
+		if updated, _, ok := parseDate(p.GetString("updated"), location); ok {
+			item.Updated = updated
+		}
+
+		if s.config.GetBool("feed.content") {
+			item.Content, err = s.feedContent(page)
+			if err != nil {
+				s.warn("Warning: cannot render the feed content of page "+pageID+".", err)
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func atomXML(title string, siteURL string, feedURL string, items []*feedItem) ([]byte, error) {
+	feed := &atomFeed{
+		Title: title,
+		ID:    feedURL,
+		Links: []*atomLink{{Href: siteURL}, {Href: feedURL, Rel: "self"}},
+	}
+	var updated time.Time
+	for _, item := range items {
+		if item.Updated.After(updated) {
+			updated = item.Updated
+		}
+		entry := &atomEntry{
+			Title:     item.Title,
+			ID:        "urn:uuid:" + item.ID,
+			Link:      &atomLink{Href: item.URL},
+			Published: item.Date.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+		}
+		if item.Description != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Description}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: item.Content}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+
+	return marshalXML(feed)
+}
+
+func rssXML(title string, siteURL string, items []*feedItem) ([]byte, error) {
+	channel := &rssChannel{
+		Title:       title,
+		Link:        siteURL,
+		Description: title,
+	}
+	var updated time.Time
+	for _, item := range items {
+		if item.Updated.After(updated) {
+			updated = item.Updated
+		}
+		channel.Items = append(channel.Items, &rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        &rssGUID{IsPermaLink: true, Value: item.URL},
+			PubDate:     item.Date.Format(time.RFC1123Z),
+			Description: item.Description,
+			Content:     item.Content,
+		})
+	}
+	if !updated.IsZero() {
+		channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	return marshalXML(&rssFeed{Version: "2.0", Channel: channel})
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// the atom.xml and rss.xml of items, saved to dir relative to the static dir
+func (s *Syncer) feedFiles(dir string, items []*feedItem) (map[string][]byte, error) {
+	if limit := s.config.GetInt("feed.limit"); limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	title := s.config.GetString("feed.title")
+	siteURL := s.config.GetString("url")
+	atomURL := absoluteURL(siteURL, path.Join(dir, "atom.xml"))
+
+	atom, err := atomXML(title, siteURL, atomURL, items)
+	if err != nil {
+		return nil, err
+	}
+	rss, err := rssXML(title, siteURL, items)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		path.Join(s.layout.StaticDir, dir, "atom.xml"): atom,
+		path.Join(s.layout.StaticDir, dir, "rss.xml"):  rss,
+	}, nil
+}
+
+// the feeds of each post database in feeds/<pageID>, and the combined one in the static dir
+// the items of combined feed are sorted by date, the newest first
+// a feed failed to generate has nil content, so it's kept as is
+func (s *Syncer) feeds() map[string][]byte {
+	if !s.config.GetBool("feed.enable") {
+		return nil
+	}
+	if s.config.GetString("url") == "" {
+		s.warn("Warning: url is not set, the feeds are not generated.")
+		return nil
+	}
+
+	files := make(map[string][]byte)
+	var allItems []*feedItem
+	for _, db := range s.dbs {
+		items := s.feedItems(db)
+		allItems = append(allItems, items...)
+
+		dir := path.Join(s.config.GetString("feed.dir"), notionapi.ToNoDashID(db.pageID))
+		dbFiles, err := s.feedFiles(dir, items)
+		if err != nil {
+			s.warn("Warning: cannot generate the feeds of database "+db.id()+".", err)
+			files[path.Join(s.layout.StaticDir, dir, "atom.xml")] = nil
+			files[path.Join(s.layout.StaticDir, dir, "rss.xml")] = nil
+			continue
+		}
+		for filename, data := range dbFiles {
+			files[filename] = data
+		}
+	}
+	// the limit of combined feed keeps the newest posts of all databases
+	sort.SliceStable(allItems, func(i, j int) bool {
+		return allItems[i].Date.After(allItems[j].Date)
+	})
+	combinedFiles, err := s.feedFiles("", allItems)
+	if err != nil {
+		s.warn("Warning: cannot generate the feeds.", err)
+		files[path.Join(s.layout.StaticDir, "atom.xml")] = nil
+		files[path.Join(s.layout.StaticDir, "rss.xml")] = nil
+	}
+	for filename, data := range combinedFiles {
+		files[filename] = data
+	}
+
+	log.Println("Generate", len(files), "feeds")
+	return files
+}
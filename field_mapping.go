@@ -0,0 +1,130 @@
+package notionblog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// fieldMapping maps a column to a key of front matter, it's an item of frontmatter.fields in config
+type fieldMapping struct {
+	// Column is the name or the property id of column
+	Column string `mapstructure:"column"`
+	// Key is the key of front matter, default to the name of column
+	Key string `mapstructure:"key"`
+
+	// the transforms, applied in the order of fields
+	Default    interface{} `mapstructure:"default"` // used if the value is empty
+	Split      string      `mapstructure:"split"`   // split the text into a list by the separator
+	Lowercase  bool        `mapstructure:"lowercase"`
+	Slugify    bool        `mapstructure:"slugify"`
+	DateFormat string      `mapstructure:"date_format"` // in the layout of go, e.g. 2006-01-02
+}
+
+// read frontmatter.fields in config
+// it's a list, because the keys of map (the names of columns) will be lowercased by viper
+func (s *Syncer) fieldMappings() ([]*fieldMapping, error) {
+	var mappings []*fieldMapping
+	if err := s.config.UnmarshalKey("frontmatter.fields", &mappings); err != nil {
+		return nil, fmt.Errorf("cannot read frontmatter.fields: %v", err)
+	}
+	for _, mapping := range mappings {
+		if mapping.Column == "" {
+			return nil, fmt.Errorf("the column of frontmatter.fields must not be empty")
+		}
+		if mapping.Key == "" {
+			mapping.Key = trimAndConvertSpace(mapping.Column)
+		}
+	}
+	return mappings, nil
+}
+
+// find the mapping of column by its property id or name
+func findFieldMapping(mappings []*fieldMapping, id string, name string) *fieldMapping {
+	for _, mapping := range mappings {
+		if mapping.Column == id || mapping.Column == name || trimAndConvertSpace(mapping.Column) == trimAndConvertSpace(name) {
+			return mapping
+		}
+	}
+	return nil
+}
+
+// apply the transforms to value, the value is returned as is if there is no mapping
+func (m *fieldMapping) apply(value interface{}, location *time.Location) interface{} {
+	if m == nil {
+		return value
+	}
+
+	if (value == nil || value == "") && m.Default != nil {
+		value = m.Default
+	}
+	if text, ok := value.(string); ok && m.Split != "" {
+		value = splitValue(text, m.Split)
+	}
+	if m.Lowercase {
+		value = mapStrings(value, strings.ToLower)
+	}
+	if m.Slugify {
+		value = mapStrings(value, slugify)
+	}
+	if text, ok := value.(string); ok && m.DateFormat != "" {
+		if t, _, ok := parseDate(text, location); ok {
+			value = t.Format(m.DateFormat)
+		}
+	}
+	return value
+}
+
+// split text by sep, the items are trimmed and the empty ones are dropped
+func splitValue(text string, sep string) interface{} {
+	var values []string
+	for _, v := range strings.Split(text, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// apply fn to the strings in value
+func mapStrings(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case []string:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = fn(item)
+		}
+		return values
+	case [][]string:
+		values := make([][]string, len(v))
+		for i, item := range v {
+			values[i] = mapStrings(item, fn).([]string)
+		}
+		return values
+	}
+	return value
+}
+
+// lowercase the text, and join the words (letters and digits of any language) with dashes
+func slugify(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() != 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
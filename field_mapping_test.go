@@ -0,0 +1,22 @@
+package notionblog
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+	"time"
+)
+
+func TestFieldMappingApply(t *testing.T) {
+	m := &fieldMapping{Split: ";", Slugify: true}
+	assert.Equal(t, m.apply("Go Tips; Hello, World ;", time.UTC), []string{"go-tips", "hello-world"})
+
+	m = &fieldMapping{Default: "draft", Lowercase: true}
+	assert.Equal(t, m.apply(nil, time.UTC), "draft")
+	assert.Equal(t, m.apply("Published", time.UTC), "published")
+
+	m = &fieldMapping{DateFormat: "2006/01/02"}
+	assert.Equal(t, m.apply("2021-03-04 10:00", time.UTC), "2021/03/04")
+
+	var none *fieldMapping
+	assert.Equal(t, none.apply("A", time.UTC), "A")
+}
@@ -0,0 +1,351 @@
+package notionblog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// formulas and rollups are computed by notion and never saved in the properties of pages,
+// so they are computed again from the other columns here. Only the formulas of one level
+// (a function of columns and constants) and the common aggregations of rollups are supported.
+
+// warn once for each column which cannot be computed
+func (f *FrontMatter) cannotCompute(name string, err interface{}) {
+	if f.warned == nil {
+		f.warned = make(map[string]struct{})
+	}
+	if _, ok := f.warned[name]; ok {
+		return
+	}
+	f.warned[name] = struct{}{}
+	f.s.warn("Warning: cannot compute the value of column "+name+".", err)
+}
+
+func (f *FrontMatter) columnSchema(name string) *notionapi.ColumnSchema {
+	if idMap, ok := f.nameToId[name]; ok {
+		return idMap.Schema
+	}
+	return nil
+}
+
+func (f *FrontMatter) formulaValue(name string, block *notionapi.Block) interface{} {
+	schema := f.columnSchema(name)
+	if schema == nil || schema.Formula == nil {
+		f.cannotCompute(name, "the formula is unknown")
+		return nil
+	}
+	formula := schema.Formula
+
+	var value interface{}
+	var err error
+	switch formula.Type {
+	case "property":
+		value, err = f.formulaProperty(formula.Name, block)
+	case "function", "operator":
+		args := make([]interface{}, len(formula.Args))
+		for i, arg := range formula.Args {
+			if args[i], err = f.formulaArg(arg, block); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			value, err = formulaFunction(formula.Name, args)
+		}
+	default:
+		err = fmt.Errorf("%s is not supported", formula.Type)
+	}
+	if err != nil {
+		f.cannotCompute(name, err)
+		return nil
+	}
+
+	switch formula.ResultType {
+	case "number":
+		if number, err := toNumber(value); err == nil {
+			return number
+		}
+		return nil
+	case "checkbox":
+		return toBool(value)
+	case "text":
+		return formatValue(value)
+	}
+	return value
+}
+
+func (f *FrontMatter) formulaArg(arg notionapi.FormulaArg, block *notionapi.Block) (interface{}, error) {
+	switch arg.Type {
+	case "property":
+		if arg.Name == nil {
+			return nil, errors.New("the column of prop() is unknown")
+		}
+		return f.formulaProperty(*arg.Name, block)
+	case "constant":
+		if arg.Value == nil {
+			return nil, errors.New("the constant is unknown")
+		}
+		if arg.ValueType != nil && *arg.ValueType == "number" {
+			return toNumber(*arg.Value)
+		}
+		return *arg.Value, nil
+	}
+	return nil, fmt.Errorf("nested %s is not supported", arg.Type)
+}
+
+// the value of column used in formula, numbers are float64
+func (f *FrontMatter) formulaProperty(name string, block *notionapi.Block) (interface{}, error) {
+	name = trimAndConvertSpace(name)
+	idMap, ok := f.nameToId[name]
+	if !ok {
+		return nil, fmt.Errorf("column %s is not found", name)
+	}
+	value := readFrontMatterValue(block, f, name)
+	if idMap.Type == notionapi.ColumnTypeNumber {
+		if value == nil {
+			return 0.0, nil
+		}
+		return toNumber(value)
+	}
+	return value, nil
+}
+
+func toNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot convert %v to number", value)
+}
+
+func toBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case int64:
+		return v != 0
+	case nil:
+		return false
+	}
+	return formatValue(value) != ""
+}
+
+// the text of value, as the format() of notion
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// the numbers of args, fails if any arg is not a number
+func toNumbers(args []interface{}) ([]float64, error) {
+	numbers := make([]float64, len(args))
+	for i, arg := range args {
+		number, err := toNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+func formulaFunction(name string, args []interface{}) (interface{}, error) {
+	argc := map[string]int{
+		"add": 2, "subtract": 2, "multiply": 2, "divide": 2, "mod": 2, "pow": 2,
+		"equal": 2, "unequal": 2, "larger": 2, "largerEq": 2, "smaller": 2, "smallerEq": 2,
+		"and": 2, "or": 2, "if": 3,
+		"not": 1, "unaryMinus": 1, "abs": 1, "round": 1, "ceil": 1, "floor": 1,
+		"toNumber": 1, "format": 1, "length": 1, "lower": 1, "upper": 1, "empty": 1,
+	}
+	if n, ok := argc[name]; ok && len(args) != n {
+		return nil, fmt.Errorf("%s needs %d arguments", name, n)
+	}
+
+	switch name {
+	case "concat", "join":
+		texts := make([]string, len(args))
+		for i, arg := range args {
+			texts[i] = formatValue(arg)
+		}
+		if name == "join" && len(texts) > 0 {
+			return strings.Join(texts[1:], texts[0]), nil
+		}
+		return strings.Join(texts, ""), nil
+	case "add":
+		// + adds numbers and concats texts
+		if numbers, err := toNumbers(args); err == nil {
+			return numbers[0] + numbers[1], nil
+		}
+		return formatValue(args[0]) + formatValue(args[1]), nil
+	case "subtract", "multiply", "divide", "mod", "pow", "larger", "largerEq", "smaller", "smallerEq":
+		numbers, err := toNumbers(args)
+		if err != nil {
+			return nil, err
+		}
+		a, b := numbers[0], numbers[1]
+		switch name {
+		case "subtract":
+			return a - b, nil
+		case "multiply":
+			return a * b, nil
+		case "divide":
+			return a / b, nil
+		case "mod":
+			return math.Mod(a, b), nil
+		case "pow":
+			return math.Pow(a, b), nil
+		case "larger":
+			return a > b, nil
+		case "largerEq":
+			return a >= b, nil
+		case "smaller":
+			return a < b, nil
+		default:
+			return a <= b, nil
+		}
+	case "unaryMinus", "abs", "round", "ceil", "floor", "toNumber":
+		number, err := toNumber(args[0])
+		if err != nil {
+			if name == "toNumber" {
+				return nil, nil
+			}
+			return nil, err
+		}
+		switch name {
+		case "unaryMinus":
+			return -number, nil
+		case "abs":
+			return math.Abs(number), nil
+		case "round":
+			return math.Round(number), nil
+		case "ceil":
+			return math.Ceil(number), nil
+		case "floor":
+			return math.Floor(number), nil
+		default:
+			return number, nil
+		}
+	case "equal":
+		return formatValue(args[0]) == formatValue(args[1]), nil
+	case "unequal":
+		return formatValue(args[0]) != formatValue(args[1]), nil
+	case "and":
+		return toBool(args[0]) && toBool(args[1]), nil
+	case "or":
+		return toBool(args[0]) || toBool(args[1]), nil
+	case "not":
+		return !toBool(args[0]), nil
+	case "if":
+		if toBool(args[0]) {
+			return args[1], nil
+		}
+		return args[2], nil
+	case "format":
+		return formatValue(args[0]), nil
+	case "length":
+		return float64(len([]rune(formatValue(args[0])))), nil
+	case "lower":
+		return strings.ToLower(formatValue(args[0])), nil
+	case "upper":
+		return strings.ToUpper(formatValue(args[0])), nil
+	case "empty":
+		return !toBool(args[0]), nil
+	}
+	return nil, fmt.Errorf("function %s is not supported", name)
+}
+
+// the rollup is aggregated from the target column of the related pages
+func (f *FrontMatter) rollupValue(name string, block *notionapi.Block) interface{} {
+	schema := f.columnSchema(name)
+	if schema == nil || schema.RelationProperty == "" || schema.TargetProperty == "" {
+		f.cannotCompute(name, "the relation of rollup is unknown")
+		return nil
+	}
+
+	relatedIDs := propertyAttrs(block.Properties[schema.RelationProperty], notionapi.AttrPage)
+	var values []string
+	for _, id := range relatedIDs {
+		related := f.s.relatedBlock(block, id)
+		if related == nil {
+			continue
+		}
+		if value := propertyText(related.Properties[schema.TargetProperty]); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	value, err := aggregate(schema.Aggregation, values, len(relatedIDs))
+	if err != nil {
+		f.cannotCompute(name, err)
+		return nil
+	}
+	return value
+}
+
+// aggregate the values of rollup, count is the number of related pages
+func aggregate(aggregation string, values []string, count int) (interface{}, error) {
+	switch aggregation {
+	case "", "show_original":
+		return oneOrList(values), nil
+	case "show_unique", "unique":
+		return oneOrList(unique(values)), nil
+	case "count", "count_all":
+		return float64(count), nil
+	case "count_values":
+		return float64(len(values)), nil
+	case "count_unique_values":
+		return float64(len(unique(values))), nil
+	case "sum", "average", "min", "max":
+		numbers := make([]float64, 0, len(values))
+		for _, value := range values {
+			if number, err := toNumber(value); err == nil {
+				numbers = append(numbers, number)
+			}
+		}
+		if len(numbers) == 0 {
+			return nil, nil
+		}
+		result := numbers[0]
+		for _, number := range numbers[1:] {
+			switch aggregation {
+			case "sum", "average":
+				result += number
+			case "min":
+				result = math.Min(result, number)
+			case "max":
+				result = math.Max(result, number)
+			}
+		}
+		if aggregation == "average" {
+			result /= float64(len(numbers))
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("aggregation %s is not supported", aggregation)
+}
@@ -0,0 +1,38 @@
+package notionblog
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestFormulaFunction(t *testing.T) {
+	value, err := formulaFunction("add", []interface{}{1.5, 2.0})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, 3.5)
+
+	value, err = formulaFunction("add", []interface{}{"a", 2.0})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "a2")
+
+	value, err = formulaFunction("concat", []interface{}{"by ", []string{"a", "b"}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "by a, b")
+
+	value, err = formulaFunction("if", []interface{}{true, "yes", "no"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "yes")
+
+	_, err = formulaFunction("dateAdd", []interface{}{})
+	assert.Equal(t, err != nil, true)
+}
+
+func TestAggregate(t *testing.T) {
+	value, _ := aggregate("", []string{"a", "b"}, 2)
+	assert.Equal(t, value, []string{"a", "b"})
+	value, _ = aggregate("show_unique", []string{"a", "a"}, 2)
+	assert.Equal(t, value, "a")
+	value, _ = aggregate("count", []string{"a"}, 2)
+	assert.Equal(t, value, 2.0)
+	value, _ = aggregate("average", []string{"1", "2", "x"}, 3)
+	assert.Equal(t, value, 1.5)
+}
@@ -1,113 +1,118 @@
-package main
+package notionblog
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kjk/notionapi"
-	"github.com/spf13/viper"
 	"github.com/uniplaces/carbon"
 )
 
 type idToNameStructure struct {
-	Name string
-	Type string
+	Name   string
+	Type   string
+	Schema *notionapi.ColumnSchema
 }
 type idToNameMap map[string]*idToNameStructure
 type nameToIdStructure struct {
-	Id   string
-	Type string
+	Id      string
+	Type    string
+	Schema  *notionapi.ColumnSchema
+	Column  string        // the name of column in notion
+	Mapping *fieldMapping // nil if the column is not in frontmatter.fields
 }
 type nameToIdMap map[string]*nameToIdStructure
 
 type FrontMatter struct {
+	s        *Syncer
 	idToName idToNameMap
 	nameToId nameToIdMap
+	warned   map[string]struct{} // the columns which cannot be computed, only warned once
 }
 
-func getStringLikeValue(property interface{}) string {
-	v0, ok := property.([]interface{})
-	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 202")
+// the plain text of a text-like property, all segments of the rich text are joined and the formatting is dropped
+func (s *Syncer) getStringLikeValue(property interface{}) string {
+	if v, ok := property.([]interface{}); ok && len(v) == 0 {
 		return ""
 	}
-	if len(v0) != 1 {
-		log.Println("Unknown Error - renderFrontMatter - 203")
-		return ""
-	}
-	v1 := v0[0]
-	v2, ok := v1.([]interface{})
-	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 204")
-		return ""
-	}
-	if len(v2) != 1 {
-		log.Println("Unknown Error - renderFrontMatter - 205")
-		return ""
-	}
-	v3 := v2[0]
-	v, ok := v3.(string)
-	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 206")
+	spans, err := notionapi.ParseTextSpans(property)
+	if err != nil {
+		s.warn("Warning: cannot decode property.", err)
 		return ""
 	}
+	return notionapi.TextSpansToString(spans)
+}
 
-	return v
+// the options of a select or multi-select property in order, nil if there is no option
+// notion joins the options with commas, which cannot be used in the names of options
+func (s *Syncer) getOptionValues(property interface{}) []string {
+	var values []string
+	for _, v := range strings.Split(s.getStringLikeValue(property), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
 }
-func getStartDateValue(property interface{}) string {
+
+func (s *Syncer) getStartDateValue(property interface{}) string {
 	v0, ok := property.([]interface{})
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 302")
+		s.warn("Unknown Error - renderFrontMatter - 302")
 		return ""
 	}
 	if len(v0) != 1 {
-		log.Println("Unknown Error - renderFrontMatter - 303")
+		s.warn("Unknown Error - renderFrontMatter - 303")
 		return ""
 	}
 	v1 := v0[0]
 	v2, ok := v1.([]interface{})
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 304")
+		s.warn("Unknown Error - renderFrontMatter - 304")
 		return ""
 	}
 	if len(v2) != 2 {
-		log.Println("Unknown Error - renderFrontMatter - 305")
+		s.warn("Unknown Error - renderFrontMatter - 305")
 		return ""
 	}
 	v3 := v2[1]
 	v4, ok := v3.([]interface{})
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 306")
+		s.warn("Unknown Error - renderFrontMatter - 306")
 		return ""
 	}
 	if len(v4) != 1 {
-		log.Println("Unknown Error - renderFrontMatter - 307")
+		s.warn("Unknown Error - renderFrontMatter - 307")
 		return ""
 	}
 	v5 := v4[0]
 	v6, ok := v5.([]interface{})
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 308")
+		s.warn("Unknown Error - renderFrontMatter - 308")
 		return ""
 	}
 	if len(v6) != 2 {
-		log.Println("Unknown Error - renderFrontMatter - 309")
+		s.warn("Unknown Error - renderFrontMatter - 309")
 		return ""
 	}
 	v7 := v6[1]
 	v8, ok := v7.(map[string]interface{})
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 310")
+		s.warn("Unknown Error - renderFrontMatter - 310")
 		return ""
 	}
 	v9, ok := v8["start_date"]
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 311")
+		s.warn("Unknown Error - renderFrontMatter - 311")
 		return ""
 	}
 	v, ok := v9.(string)
 	if !ok {
-		log.Println("Unknown Error - renderFrontMatter - 312")
+		s.warn("Unknown Error - renderFrontMatter - 312")
 		return ""
 	}
 
@@ -122,57 +127,64 @@ func getStartDateValue(property interface{}) string {
 
 	return v
 }
-func milliTimeStampToISO8601String(timestamp int64) string {
-	t, err := carbon.CreateFromTimestamp(timestamp/1000, viper.GetString("user.timezone"))
+func (s *Syncer) milliTimeStampToISO8601String(timestamp int64) string {
+	t, err := carbon.CreateFromTimestamp(timestamp/1000, s.config.GetString("user.timezone"))
 	if err != nil {
 		return ""
 	}
 	return t.ISO8601String()
 }
 
-func (f *FrontMatter) getFrontMatterForType(name, propertyType string, property interface{}, block *notionapi.Block) string {
+func (s *Syncer) milliTimeStampToTime(timestamp int64) time.Time {
+	t := time.Unix(0, timestamp*int64(time.Millisecond))
+	if location, err := time.LoadLocation(s.config.GetString("user.timezone")); err == nil {
+		t = t.In(location)
+	}
+	return t
+}
+
+// get the value of property, see Field.Value for the types of value
+// nil is returned if the value is empty
+func (f *FrontMatter) getFrontMatterForType(name, propertyType string, property interface{}, block *notionapi.Block) interface{} {
 
 	if name == "title" {
-		return getStringLikeValue(property)
+		return f.s.getStringLikeValue(property)
 	}
 	if name == "tags" {
-		v := getStringLikeValue(property)
-		if v != "" {
-			return "[" + v + "]"
-		} else {
-			return ""
+		if values := f.s.getOptionValues(property); values != nil {
+			return values
 		}
+		return nil
 	}
 	if name == "categories" {
-		v := getStringLikeValue(property)
-		if v == "" {
-			return ""
+		categories := f.s.getOptionValues(property)
+		if categories == nil {
+			return nil
 		}
 
-		var b strings.Builder
-		b.Grow(len(v))
-		b.WriteByte('[')
-		categories := strings.Split(v, ",")
-		for i, category := range categories {
-			if i != 0 {
-				b.WriteByte(',')
-			}
-			b.WriteByte('[')
-			parts := strings.Split(category, "/")
-			for j, part := range parts {
-				if j != 0 {
-					b.WriteByte(',')
+		// a/b is the sub category b of a
+		values := make([][]string, 0, len(categories))
+		for _, category := range categories {
+			var parts []string
+			for _, part := range strings.Split(category, "/") {
+				if part = strings.TrimSpace(part); part != "" {
+					parts = append(parts, part)
 				}
-				b.WriteString(part)
 			}
-			b.WriteByte(']')
+			if parts != nil {
+				values = append(values, parts)
+			}
 		}
-
-		b.WriteByte(']')
-		return b.String()
+		if len(values) == 0 {
+			return nil
+		}
+		return values
 	}
 	if name == "url" {
-		url := getStringLikeValue(property)
+		url := f.s.getStringLikeValue(property)
+		if url == "" {
+			return nil
+		}
 		if url[0] != '/' {
 			url = url[1:]
 		}
@@ -182,203 +194,340 @@ func (f *FrontMatter) getFrontMatterForType(name, propertyType string, property
 		return url
 	}
 	if propertyType == notionapi.ColumnTypeTitle {
-		return getStringLikeValue(property)
+		return f.s.getStringLikeValue(property)
 	}
 	if propertyType == notionapi.ColumnTypeText {
-		return getStringLikeValue(property)
+		return f.s.getStringLikeValue(property)
 	}
 	if propertyType == notionapi.ColumnTypeNumber {
-		return getStringLikeValue(property)
+		return numberValue(f.s.getStringLikeValue(property))
 	}
 	if propertyType == notionapi.ColumnTypeSelect {
-		return getStringLikeValue(property)
+		return f.s.getStringLikeValue(property)
 	}
 	if propertyType == notionapi.ColumnTypeMultiSelect {
-		return getStringLikeValue(property)
+		if values := f.s.getOptionValues(property); values != nil {
+			return values
+		}
+		return nil
 	}
 	if propertyType == notionapi.ColumnTypeCheckbox {
-		v := getStringLikeValue(property)
-		if v == "Yes" {
-			return "true"
-		} else {
-			return "false"
-		}
+		v := f.s.getStringLikeValue(property)
+		return v == "Yes"
 	}
 	if propertyType == notionapi.ColumnTypeCreatedTime {
-		return milliTimeStampToISO8601String(block.CreatedTime)
+		return f.s.milliTimeStampToISO8601String(block.CreatedTime)
 	}
 	if propertyType == notionapi.ColumnTypeLastEditedTime {
-		return milliTimeStampToISO8601String(block.LastEditedTime)
+		return f.s.milliTimeStampToISO8601String(block.LastEditedTime)
 	}
 	if propertyType == notionapi.ColumnTypeDate {
-		return getStartDateValue(property)
+		return f.s.getStartDateValue(property)
+	}
+	if propertyType == notionapi.ColumnTypeURL || propertyType == notionapi.ColumnTypeEmail || propertyType == notionapi.ColumnTypePhoneNumber {
+		return propertyText(property)
+	}
+	if propertyType == notionapi.ColumnTypePerson {
+		return f.s.personValue(property, block)
+	}
+	if propertyType == notionapi.ColumnTypeFile {
+		return filesValue(property)
+	}
+	if propertyType == notionapi.ColumnTypeRelation {
+		return f.s.relationValue(property, block)
+	}
+	if value := f.computedValue(name, propertyType, block); value != nil {
+		return value
 	}
 
 	// not support any other values
-	return ""
+	return nil
+}
+
+// the number of number column, an int64 if it's integral, so it's written as an integer (e.g. the weight of zola)
+func numberValue(text string) interface{} {
+	if text == "" {
+		return nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return text
+	}
+	if number == math.Trunc(number) && math.Abs(number) < 1<<53 {
+		return int64(number)
+	}
+	return number
 }
 
-func (f *FrontMatter) getDefaultFrontMatter(name, propertyType string, block *notionapi.Block) string {
+// the value of the columns which are computed by notion, they are not in the properties of page
+func (f *FrontMatter) computedValue(name, propertyType string, block *notionapi.Block) interface{} {
+	switch propertyType {
+	case notionapi.ColumnTypeCreatedBy:
+		return f.s.userValue(block, firstNonEmpty(block.CreatedByID, block.CreatedBy))
+	case notionapi.ColumnTypeLastEditedBy:
+		return f.s.userValue(block, firstNonEmpty(block.LastEditedByID, block.LastEditedBy))
+	case notionapi.ColumnTypeForumula:
+		return f.formulaValue(name, block)
+	case notionapi.ColumnTypeRollup:
+		return f.rollupValue(name, block)
+	}
+	return nil
+}
+
+func (f *FrontMatter) getDefaultFrontMatter(name, propertyType string, block *notionapi.Block) interface{} {
 	if name == "title" {
-		return ""
+		return nil
 	}
 	if name == "status" {
 		return "published"
 	}
-	if name == "url" {
-		return getDefaultUrlForPage(block)
-	}
 	if propertyType == notionapi.ColumnTypeCheckbox {
-		return "false"
+		return false
 	}
 	if propertyType == notionapi.ColumnTypeCreatedTime {
-		return milliTimeStampToISO8601String(block.CreatedTime)
+		return f.s.milliTimeStampToISO8601String(block.CreatedTime)
 	}
 	if propertyType == notionapi.ColumnTypeLastEditedTime {
-		return milliTimeStampToISO8601String(block.LastEditedTime)
+		return f.s.milliTimeStampToISO8601String(block.LastEditedTime)
 	}
-	return ""
+	return f.computedValue(name, propertyType, block)
 }
 
-func (f *FrontMatter) getSystemFrontMatter(block *notionapi.Block) map[string]string {
-	m := make(map[string]string, 2)
-	m["uuid"] = block.ID
-	if _, ok := f.nameToId["url"]; !ok {
-		m["url"] = getDefaultUrlForPage(block)
+// the front matter of page in database
+func (f *FrontMatter) fields(block *notionapi.Block) []*Field {
+	// block should be the root block of a page
+	fields := make([]*Field, 0, len(f.nameToId)+2)
+
+	for name, idMap := range f.nameToId {
+		v := f.value(name, idMap, block)
+		if v != nil && v != "" {
+			fields = append(fields, &Field{Name: name, Type: idMap.Type, Value: v})
+		}
 	}
 
-	return m
+	// system front matters
+	fields = append(fields, &Field{Name: "uuid", Value: block.ID})
+
+	return fields
+}
+
+// the value of column in page, transformed by its mapping
+func (f *FrontMatter) value(name string, idMap *nameToIdStructure, block *notionapi.Block) interface{} {
+	return idMap.Mapping.apply(f.rawValue(name, idMap, block), f.s.milliTimeStampToTime(block.CreatedTime).Location())
 }
 
-func (f *FrontMatter) render(block *notionapi.Block) string {
+// the value of column in page before the transforms
+func (f *FrontMatter) rawValue(name string, idMap *nameToIdStructure, block *notionapi.Block) interface{} {
 	// block should be the root block of a page
-	var b strings.Builder
+	if property, ok := block.Properties[idMap.Id]; ok {
+		return f.getFrontMatterForType(name, idMap.Type, property, block)
+	}
+	return f.getDefaultFrontMatter(name, idMap.Type, block)
+}
 
-	for name, idMap := range f.nameToId {
-		property, ok := block.Properties[idMap.Id]
-		var v string
-		if !ok {
-			v = f.getDefaultFrontMatter(name, idMap.Type, block)
-		} else {
-			v = f.getFrontMatterForType(name, idMap.Type, property, block)
+// the columns by the key of front matter, the keys are given by mappings or the names of columns
+func convertToNameToId(ds idToNameMap, mappings []*fieldMapping) nameToIdMap {
+	m := make(nameToIdMap, len(ds))
+	for id, schema := range ds {
+		idMap := &nameToIdStructure{
+			Id:     id,
+			Type:   schema.Type,
+			Schema: schema.Schema,
+			Column: schema.Name,
+		}
+		if schema.Schema != nil {
+			idMap.Column = schema.Schema.Name
 		}
 
-		if v != "" {
-			b.WriteString(name)
-			b.WriteString(": ")
-			b.WriteString(v)
-			b.WriteByte('\n')
+		key := trimAndConvertSpace(schema.Name)
+		if mapping := findFieldMapping(mappings, id, idMap.Column); mapping != nil {
+			key = mapping.Key
+			idMap.Mapping = mapping
+		}
+		if existed, ok := m[key]; ok && existed.Mapping != nil && idMap.Mapping == nil {
+			// the mapped column takes the key from the column named by it
+			continue
 		}
+		m[key] = idMap
 	}
+	return m
+}
+
+// columnError describes a column that does not fit the front matter
+type columnError struct {
+	column     string // the key of front matter
+	mappedFrom string // the name of column mapped to the key in frontmatter.fields, empty if not mapped
+	maybeTypes []string
+	missing    bool
+}
 
-	systemFrontMatters := f.getSystemFrontMatter(block)
-	for k, v := range systemFrontMatters {
-		b.WriteString(k)
-		b.WriteString(": ")
-		b.WriteString(v)
-		b.WriteByte('\n')
+func (e *columnError) Error() string {
+	if e.missing {
+		return fmt.Sprintf("column %s must exist", e.column)
+	}
+	if e.mappedFrom != "" {
+		return fmt.Sprintf("column %s (mapped to %s) type must be one of: %v", e.mappedFrom, e.column, e.maybeTypes)
 	}
+	return fmt.Sprintf("column %s type must be one of: %v", e.column, e.maybeTypes)
+}
 
-	return b.String()
+// fix returns the suggested fix of the error
+func (e *columnError) fix() string {
+	if e.missing {
+		return fmt.Sprintf("Add a column named %s with type %s, or map a column to %s in frontmatter.fields", e.column, strings.Join(e.maybeTypes, " or "), e.column)
+	}
+	if e.mappedFrom != "" {
+		return fmt.Sprintf("Change the type of column %s to %s, or map another column to %s in frontmatter.fields", e.mappedFrom, strings.Join(e.maybeTypes, " or "), e.column)
+	}
+	return fmt.Sprintf("Change the type of column %s to %s, or rename it if it is not used as %s", e.column, strings.Join(e.maybeTypes, " or "), e.column)
 }
 
-func convertToNameToId(ds idToNameMap) nameToIdMap {
-	m := make(nameToIdMap, len(ds))
-	for id, schema := range ds {
-		m[trimAndConvertSpace(schema.Name)] = &nameToIdStructure{
-			Id:   id,
-			Type: schema.Type,
-		}
+// schemaError contains all column errors of a database schema
+type schemaError []*columnError
+
+func (e schemaError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
 	}
-	return m
+	return strings.Join(messages, "; ")
 }
 
-func mustBeExistAndAssertType(m map[string]*nameToIdStructure, key string, maybeTypes ...string) {
+func mustBeExistAndAssertType(m map[string]*nameToIdStructure, key string, maybeTypes ...string) *columnError {
 	v, ok := m[key]
 	if !ok {
-		log.Fatal("Column ", key, " must exist.")
+		return &columnError{column: key, maybeTypes: maybeTypes, missing: true}
 	}
 
 	for _, t := range maybeTypes {
 		if v.Type == t {
-			return
+			return nil
 		}
 	}
-	log.Fatal("Column ", key, " type must be one of: ", maybeTypes)
+	return typeError(key, v, maybeTypes)
+}
+
+func typeError(key string, v *nameToIdStructure, maybeTypes []string) *columnError {
+	err := &columnError{column: key, maybeTypes: maybeTypes}
+	if v.Mapping != nil {
+		err.mappedFrom = v.Column
+	}
+	return err
 }
 
-func mayBeExistAndAssertType(m map[string]*nameToIdStructure, key string, maybeTypes ...string) {
+func mayBeExistAndAssertType(m map[string]*nameToIdStructure, key string, maybeTypes ...string) *columnError {
 	v, ok := m[key]
 	if !ok {
-		return
+		return nil
 	}
 
 	for _, t := range maybeTypes {
 		if v.Type == t {
-			return
+			return nil
 		}
 	}
-	log.Fatal("Column ", key, " type must be one of: ", maybeTypes)
+	return typeError(key, v, maybeTypes)
 }
 
 func mustNotBeExist(m map[string]*nameToIdStructure, key string) {
 	delete(m, key)
 }
 
-func newFrontMatter(ds idToNameMap) *FrontMatter {
-	m := convertToNameToId(ds)
+func newFrontMatter(s *Syncer, ds idToNameMap) (*FrontMatter, error) {
+	mappings, err := s.fieldMappings()
+	if err != nil {
+		return nil, err
+	}
+	m := convertToNameToId(ds, mappings)
 
 	f := &FrontMatter{
+		s:        s,
 		idToName: ds,
 		nameToId: m,
+		warned:   make(map[string]struct{}),
 	}
 
-	// hexo
-	mustBeExistAndAssertType(m, "title", notionapi.ColumnTypeTitle)
-	mayBeExistAndAssertType(m, "categories", notionapi.ColumnTypeSelect, notionapi.ColumnTypeMultiSelect)
-	mayBeExistAndAssertType(m, "tags", notionapi.ColumnTypeMultiSelect)
-	mayBeExistAndAssertType(m, "date", notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime)
-	mayBeExistAndAssertType(m, "updated", notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime)
-	mayBeExistAndAssertType(m, "comments", notionapi.ColumnTypeCheckbox)
-
-	// special
-	mayBeExistAndAssertType(m, "url", notionapi.ColumnTypeText)
-	mayBeExistAndAssertType(m, "status", notionapi.ColumnTypeSelect)
-
-	// theme - next
-	mayBeExistAndAssertType(m, "description", notionapi.ColumnTypeText)
+	var errs schemaError
+	for _, column := range s.target.Columns() {
+		if column.Reserved {
+			mustNotBeExist(m, column.Name)
+			continue
+		}
 
-	// reserve
-	mustNotBeExist(m, "id")
-	mustNotBeExist(m, "uuid")
-	mustNotBeExist(m, "post_title")
-	mustNotBeExist(m, "permalink")
-	mustNotBeExist(m, "filename")
+		var err *columnError
+		if column.Required {
+			err = mustBeExistAndAssertType(m, column.Name, column.Types...)
+		} else {
+			err = mayBeExistAndAssertType(m, column.Name, column.Types...)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errs
+	}
 
-	return f
+	return f, nil
 }
 
-func readFrontMatterValue(block *notionapi.Block, f *FrontMatter, name string) string {
+func readFrontMatterValue(block *notionapi.Block, f *FrontMatter, name string) interface{} {
 	// block should be the page.Root()
 	idMap, ok := f.nameToId[name]
 	if !ok {
-		return ""
+		return nil
 	}
-	property, ok := block.Properties[idMap.Id]
-	if !ok { // not exist
-		return f.getDefaultFrontMatter(name, idMap.Type, block)
-	} else {
-		return f.getFrontMatterForType(name, idMap.Type, property, block)
+	return f.value(name, idMap, block)
+}
+
+// the time of date column name in page, in the time zone of the date, or the timezone of user if it's not given
+func (f *FrontMatter) readTime(block *notionapi.Block, name string) (time.Time, bool) {
+	idMap, ok := f.nameToId[name]
+	if !ok {
+		return time.Time{}, false
 	}
+	location := f.s.milliTimeStampToTime(block.CreatedTime).Location()
+	if idMap.Type == notionapi.ColumnTypeDate {
+		if timeZone := propertyTimeZone(block.Properties[idMap.Id]); timeZone != "" {
+			if l, err := time.LoadLocation(timeZone); err == nil {
+				location = l
+			}
+		}
+	}
+	// the date format of mapping is only for the output
+	date, _ := f.rawValue(name, idMap, block).(string)
+	t, _, ok := parseDate(date, location)
+	return t, ok
 }
 
-func checkIfPublished(page *notionapi.Page, f *FrontMatter) bool {
-	status := readFrontMatterValue(page.Root(), f, "status")
-	trueValues := []string{"published", getAlias("published", "Published")}
+// a published page is held back until its date (scheduled), and unpublished once it expires
+// block should be the root block of page
+func (s *Syncer) checkIfPublished(block *notionapi.Block, f *FrontMatter) (published bool, scheduled bool) {
+	status, _ := readFrontMatterValue(block, f, "status").(string)
+	trueValues := []string{"published", s.getAlias("published", "Published")}
 	for _, trueValue := range trueValues {
 		if strings.ToLower(status) == strings.ToLower(trueValue) {
-			return true
+			published = true
 		}
 	}
-	return false
+	if !published {
+		return false, false
+	}
+
+	now := time.Now()
+	if date, ok := f.readTime(block, "date"); ok && date.After(now) {
+		log.Println("Page", block.ID, "is scheduled at", date.Format(time.RFC3339))
+		s.report.add(&s.report.ScheduledPages, block.ID)
+		return false, true
+	}
+	// an expires column of other types is an ordinary field
+	if idMap, ok := f.nameToId["expires"]; !ok || idMap.Type != notionapi.ColumnTypeDate {
+		return true, false
+	}
+	if expires, ok := f.readTime(block, "expires"); ok && !expires.After(now) {
+		log.Println("Page", block.ID, "expired at", expires.Format(time.RFC3339))
+		s.report.add(&s.report.ExpiredPages, block.ID)
+		return false, false
+	}
+	return true, false
 }
@@ -0,0 +1,67 @@
+package notionblog
+
+import (
+	"github.com/kjk/notionapi"
+	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
+	"testing"
+	"time"
+)
+
+func TestGetStringLikeValue(t *testing.T) {
+	s := &Syncer{}
+	// a title in two segments, the second one is bold
+	property := []interface{}{
+		[]interface{}{"Hello, "},
+		[]interface{}{"World", []interface{}{[]interface{}{"b"}}},
+	}
+	assert.Equal(t, s.getStringLikeValue(property), "Hello, World")
+	assert.Equal(t, s.getStringLikeValue([]interface{}{}), "")
+}
+
+func TestGetOptionValues(t *testing.T) {
+	s := &Syncer{}
+	property := []interface{}{[]interface{}{"a,b c, Go/Web"}}
+	assert.Equal(t, s.getOptionValues(property), []string{"a", "b c", "Go/Web"})
+	assert.Equal(t, s.getOptionValues([]interface{}{[]interface{}{""}}) == nil, true)
+}
+
+func TestCheckIfPublished(t *testing.T) {
+	s := &Syncer{config: viper.New(), report: newReport()}
+	f := &FrontMatter{s: s, nameToId: nameToIdMap{
+		"status":  {Id: "s", Type: notionapi.ColumnTypeSelect},
+		"date":    {Id: "d", Type: notionapi.ColumnTypeDate},
+		"expires": {Id: "e", Type: notionapi.ColumnTypeDate},
+	}}
+	date := func(date string) interface{} {
+		return []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", map[string]interface{}{"type": "date", "start_date": date}}}}}
+	}
+	page := func(properties map[string]interface{}) *notionapi.Block {
+		properties["s"] = []interface{}{[]interface{}{"Published"}}
+		return &notionapi.Block{ID: "11112222-aaaa-bbbb-cccc-ddddeeeeffff", Properties: properties}
+	}
+
+	check := func(properties map[string]interface{}) []bool {
+		published, scheduled := s.checkIfPublished(page(properties), f)
+		return []bool{published, scheduled}
+	}
+
+	assert.Equal(t, check(map[string]interface{}{}), []bool{true, false})
+	assert.Equal(t, check(map[string]interface{}{"d": date("2000-01-01")}), []bool{true, false})
+	assert.Equal(t, check(map[string]interface{}{"d": date("2999-01-01")}), []bool{false, true})
+	assert.Equal(t, s.report.ScheduledPages, []string{"11112222-aaaa-bbbb-cccc-ddddeeeeffff"})
+	assert.Equal(t, check(map[string]interface{}{"e": date("2000-01-01")}), []bool{false, false})
+	assert.Equal(t, s.report.ExpiredPages, []string{"11112222-aaaa-bbbb-cccc-ddddeeeeffff"})
+	assert.Equal(t, check(map[string]interface{}{"e": date("2999-01-01")}), []bool{true, false})
+
+	// an expires column which is not a date is not the expiry
+	f.nameToId["expires"] = &nameToIdStructure{Id: "e", Type: notionapi.ColumnTypeText}
+	assert.Equal(t, check(map[string]interface{}{"e": []interface{}{[]interface{}{"2000-01-01"}}}), []bool{true, false})
+
+	// the time zone of date is used instead of the timezone of user
+	zoned := []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", map[string]interface{}{
+		"type": "datetime", "start_date": "2021-03-04", "start_time": "10:00", "time_zone": "Asia/Tokyo"}}}}}
+	when, ok := f.readTime(page(map[string]interface{}{"d": zoned}), "date")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, when.UTC().Format(time.RFC3339), "2021-03-04T01:00:00Z")
+}
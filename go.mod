@@ -6,14 +6,15 @@ require (
 	github.com/google/uuid v1.2.0 // indirect
 	github.com/kjk/notionapi v0.0.0-20210312181036-c1df7a1b08cd
 	github.com/magiconair/properties v1.8.1
-	github.com/pelletier/go-toml v1.6.0 // indirect
+	github.com/pelletier/go-toml v1.6.0
 	github.com/spf13/afero v1.2.2 // indirect
 	github.com/spf13/cast v1.3.1 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.6.2
 	github.com/uniplaces/carbon v0.1.6
+	github.com/yuin/goldmark v1.4.1
 	golang.org/x/sys v0.0.0-20200217220822-9197077df867 // indirect
 	gopkg.in/ini.v1 v1.52.0 // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,127 @@
+package notionblog
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// InitOptions is used to configure Init
+type InitOptions struct {
+	// DatabaseURL is the url of the notion database, must contain the ?v= view parameter
+	DatabaseURL string
+	// Token is the token_v2 written to config, token_v2 in the environment will be used to connect if empty,
+	// but it's not written to config
+	Token string
+	// Overwrite allows replacing an existing config file
+	Overwrite bool
+}
+
+const configTemplate = `# Generated by nb init
+version: 1 # Now should be fixed to 1
+target: %s # the static site generator of the blog
+%s
+database:
+  post:
+    - %s # %s
+
+# converter:
+#   force: false # set to true to rerender all pages, otherwise only rerender edited files
+# render:
+#   checkbox: false # set to true to render "To-do" block to checkbox, otherwise to normal list
+# alias:
+#   published: Published # the value of status column for published pages
+user:
+  locale: %s
+  timezone: %s # tz database time zones
+`
+
+// parse the url of a notion database to database id in format pageID+ViewID
+// the database id itself is also accepted
+func parseDatabaseURL(databaseURL string) (string, error) {
+	databaseURL = strings.TrimSpace(databaseURL)
+	if !strings.Contains(databaseURL, "/") {
+		if _, err := parseDatabaseID(databaseURL); err == nil {
+			return databaseURL, nil
+		}
+	}
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %v", err)
+	}
+
+	pageID := notionapi.ExtractNoDashIDFromNotionURL(u.Path)
+	if pageID == "" {
+		return "", errors.New("cannot find the page id in url")
+	}
+
+	viewID := notionapi.ToNoDashID(u.Query().Get("v"))
+	if viewID == "" {
+		return "", errors.New("cannot find the view id in url, please copy the url with the ?v= parameter from the database view")
+	}
+
+	return pageID + "+" + viewID, nil
+}
+
+// Init checks the database and writes _notion/config.yml for it
+func (s *Syncer) Init(opts InitOptions) error {
+	configFilename := path.Join(s.notionDir, "config.yml")
+	if fileExists(configFilename) && !opts.Overwrite {
+		return fmt.Errorf("the config file %s already exists", configFilename)
+	}
+
+	databaseID, err := parseDatabaseURL(opts.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	db, err := parseDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	if opts.Token != "" {
+		s.config.Set("token_v2", opts.Token)
+	}
+	s.initClient()
+	s.initUser()
+
+	s.dbs = []*database{db}
+	if err := s.getCollectionIDs(); err != nil {
+		return err
+	}
+	_, collection, err := s.queryDatabase(db)
+	if err != nil {
+		return err
+	}
+	if _, err := s.buildFrontMatter(collection.Schema); err != nil {
+		return fmt.Errorf("the schema of database is invalid: %v", err)
+	}
+
+	// the token from the environment is a secret, it's never copied into the config file
+	tokenLine := "token_v2: # Your notion's token, or set the NOTION_TOKEN environment variable"
+	if opts.Token != "" {
+		tokenLine = "token_v2: " + strconv.Quote(opts.Token) + " # Your notion's token"
+	}
+
+	config := fmt.Sprintf(configTemplate,
+		s.targetName,
+		tokenLine,
+		databaseID, collection.GetName(),
+		s.config.GetString("user.locale"),
+		s.config.GetString("user.timezone"),
+	)
+
+	if err := ioutil.WriteFile(configFilename, []byte(config), 0600); err != nil {
+		return fmt.Errorf("cannot write config file: %v", err)
+	}
+	log.Println("Write config to", configFilename)
+	return nil
+}
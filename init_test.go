@@ -0,0 +1,33 @@
+package notionblog
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestParseDatabaseURL(t *testing.T) {
+	const expected = "963f630adc2e443b98c7c93378c17176+4312fa9b8f8142a0832a95008cfee6c0"
+
+	for _, databaseURL := range []string{
+		"https://www.notion.so/963f630adc2e443b98c7c93378c17176?v=4312fa9b8f8142a0832a95008cfee6c0",
+		"https://www.notion.so/username/963f630adc2e443b98c7c93378c17176?v=4312fa9b8f8142a0832a95008cfee6c0",
+		"https://notion.so/username/Blog-963f630adc2e443b98c7c93378c17176?v=4312fa9b8f8142a0832a95008cfee6c0&p=11112222aaaabbbbccccddddeeeeffff",
+		"963f630adc2e443b98c7c93378c17176+4312fa9b8f8142a0832a95008cfee6c0",
+	} {
+		result, err := parseDatabaseURL(databaseURL)
+		if err != nil {
+			t.Error(databaseURL, err)
+		}
+		assert.Equal(t, result, expected)
+	}
+
+	for _, databaseURL := range []string{
+		"https://www.notion.so/963f630adc2e443b98c7c93378c17176",
+		"https://www.notion.so/login?v=4312fa9b8f8142a0832a95008cfee6c0",
+	} {
+		if _, err := parseDatabaseURL(databaseURL); err == nil {
+			t.Error("expect error for", databaseURL)
+		}
+	}
+}
@@ -1,66 +1,94 @@
-package main
+package notionblog
 
 import (
-	"github.com/kjk/notionapi"
-	"github.com/spf13/viper"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
-)
-
-const converterVersion = 1
 
-var sourceDir string
-var postsDir string
-var pagesDir string
+	"github.com/spf13/viper"
+)
 
-var converterVersionViper *viper.Viper
+const converterVersion = 6
 
-func init() {
-	converterVersionViper = viper.New()
-	converterVersionViper.SetDefault("converter", 0)
+// RenderedPage is the result of rendering a page
+type RenderedPage struct {
+	PageID string
+	// Filename is the path the markdown will be saved to
+	Filename string
+	Data     []byte
+	// Images are the images used in the page
+	Images []*Image
 }
 
-func getLastConverterVersion() int {
-	converterVersionViper.SetConfigFile(path.Join(notionDir, "version.yml"))
-	if err := converterVersionViper.ReadInConfig(); err != nil {
+func (s *Syncer) getLastConverterVersion() int {
+	s.versions = viper.New()
+	s.versions.SetDefault("converter", 0)
+	s.versions.SetConfigFile(path.Join(s.notionDir, "version.yml"))
+	if err := s.versions.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("The source/_notion/version.yml file is not exist, create one.")
+			log.Println("The _notion/version.yml file is not exist, create one.")
 		} else {
 			log.Println("Cannot open the version file, All pages will be rendered again.", err)
 		}
 	}
 
-	return converterVersionViper.GetInt("converter")
+	return s.versions.GetInt("converter")
 }
 
-func saveCurrentConverterVersion() {
-	converterVersionViper.Set("converter", converterVersion)
-	err := converterVersionViper.WriteConfigAs(path.Join(notionDir, "version.yml"))
-	if err != nil {
-		log.Println("Warning: Cannot save version file.", err)
+// the pages failed in last sync, they will be rendered again
+func (s *Syncer) getLastFailedPages() []string {
+	if s.versions == nil {
+		s.getLastConverterVersion()
 	}
+	return s.versions.GetStringSlice("failed")
 }
 
-func getFilename(pageID string) string {
-	return notionapi.ToNoDashID(pageID) + ".md"
+func (s *Syncer) saveCurrentConverterVersion(failedPages []string) {
+	if s.versions == nil {
+		s.getLastConverterVersion()
+	}
+	s.versions.Set("converter", converterVersion)
+	s.versions.Set("failed", failedPages)
+	err := s.versions.WriteConfigAs(path.Join(s.notionDir, "version.yml"))
+	if err != nil {
+		s.warn("Warning: Cannot save version file.", err)
+	}
 }
 
-func save(pageID string, data []byte) error {
-	pageID = notionapi.ToDashID(pageID)
-	var saveTo string
-
-	if _, ok := topLevelPagesMap[pageID]; ok {
-		// save to _posts folder
-		saveTo = path.Join(postsDir, getFilename(pageID))
+// get the path the markdown of page will be saved to
+func (s *Syncer) getSavePath(page *Page) string {
+	if page.Post {
+		// save to posts folder
+		return path.Join(s.layout.PostsDir, s.target.Filename(page))
 	} else {
 		// save to pages folder
-		saveTo = path.Join(pagesDir, getFilename(pageID))
+		return path.Join(s.layout.PagesDir, s.target.Filename(page))
 	}
+}
 
+// get the path recorded in tree.yml for page, which is deleted with the page
+// it's the dir of page bundle (ends with a slash) for BundleTarget, otherwise the markdown file
+func (s *Syncer) getRecordPath(page *Page) string {
+	bundle, ok := s.target.(BundleTarget)
+	if !ok {
+		return s.getSavePath(page)
+	}
+	dir := s.layout.PagesDir
+	if page.Post {
+		dir = s.layout.PostsDir
+	}
+	return path.Join(dir, bundle.BundleDir(page)) + "/"
+}
+
+func save(saveTo string, data []byte) error {
 	log.Println("Save To", saveTo)
 
+	if err := os.MkdirAll(path.Dir(saveTo), 0755); err != nil {
+		return err
+	}
 	err := ioutil.WriteFile(saveTo, data, 0644)
 	if err != nil {
 		return err
@@ -69,53 +97,118 @@ func save(pageID string, data []byte) error {
 
 }
 
-func remove(pageID string) {
-	filename := getFilename(pageID)
-
-	postFilename := path.Join(postsDir, filename)
-	_ = os.Remove(postFilename)
-
-	pageFilename := path.Join(pagesDir, filename)
-	_ = os.Remove(pageFilename)
-}
-
-func notionToMarkdown(pageID string) []byte {
-	page, err := downloader.ReadPageFromCache(pageID)
+func (s *Syncer) notionToMarkdown(pageID string) ([]byte, []*Image, error) {
+	page, err := s.readPageFromCache(pageID)
 	if err != nil {
-		log.Println("Cannot generate markdown for page ", pageID, ":", err)
-		return []byte{}
+		return nil, nil, err
 	}
 
-	return pageToMarkdown(page)
+	return s.pageToMarkdown(page)
 }
 
-func getReRenderedPages() []string {
+func (s *Syncer) getReRenderedPages() []string {
 
 	if func() bool { // check if rerender all
-		if viper.GetBool("converter.force") {
+		if s.offline {
+			return true
+		}
+		if s.config.GetBool("converter.force") {
 			return true
 		}
-		if getLastConverterVersion() != converterVersion {
+		if s.getLastConverterVersion() != converterVersion {
 			return true
 		}
 
 		return false
 	}() {
 		log.Println("Warning: will rerender all pages.")
-		return allPages
+		return s.allPages
 	} else {
-		return updatedPages
+		pages := s.updatedPages
+		for _, pageID := range s.getLastFailedPages() {
+			if _, ok := s.allPagesMap[pageID]; ok {
+				pages = append(pages, pageID)
+			}
+		}
+		return unique(pages)
 	}
 }
 
-func generateMarkdown() {
-	for _, pageID := range getReRenderedPages() {
+// Render converts the pages in plan to markdown, nothing will be written
+func (s *Syncer) Render(plan *Plan) ([]*RenderedPage, error) {
+	var pages []*RenderedPage
+	err := s.timePhase("render", func() error {
+		var err error
+		pages, err = s.render(plan)
+		return err
+	})
+	return pages, err
+}
+
+func (s *Syncer) render(plan *Plan) ([]*RenderedPage, error) {
+	pages := make([]*RenderedPage, 0, len(plan.RenderPages))
+
+	for _, pageID := range plan.RenderPages {
 		log.Println("Render:", pageID)
-		err := save(pageID, notionToMarkdown(pageID))
+		data, images, err := s.notionToMarkdown(pageID)
 		if err != nil {
-			log.Println("Warning: fail to save Page ", pageID, ".", err)
+			// keep the last output of the page
+			s.fail(pageID, "", fmt.Errorf("cannot generate markdown: %v", err))
+			continue
 		}
+
+		page := s.getPage(pageID)
+		filename := s.getSavePath(page)
+		s.recordFile(plan, pageID, s.getRecordPath(page))
+		pages = append(pages, &RenderedPage{
+			PageID:   pageID,
+			Filename: filename,
+			Data:     data,
+			Images:   images,
+		})
+		s.report.add(&s.report.RenderedPages, pageID)
 	}
 
-	saveCurrentConverterVersion()
+	return pages, nil
+}
+
+// Write applies the plan to the blog: saves the tree, deletes unused files and cache, saves rendered pages and downloads images
+func (s *Syncer) Write(plan *Plan, pages []*RenderedPage) error {
+	if s.dryRun {
+		return errors.New("cannot write in dry-run mode")
+	}
+
+	return s.timePhase("write", func() error { return s.write(plan, pages) })
+}
+
+func (s *Syncer) write(plan *Plan, pages []*RenderedPage) error {
+	s.applyTree(plan)
+	if err := s.clearCache(); err != nil {
+		s.warn("Warning:", err)
+	}
+
+	var images []*Image
+	for _, page := range pages {
+		err := save(page.Filename, page.Data)
+		if err != nil {
+			s.fail(page.PageID, "", fmt.Errorf("cannot save page: %v", err))
+			continue
+		}
+		images = append(images, page.Images...)
+	}
+
+	if s.offline {
+		for _, image := range images {
+			if !fileExists(image.Filename) {
+				s.warn("Warning: cannot download image in offline mode:", image.Source)
+			}
+		}
+	} else {
+		s.downloadImages(images)
+	}
+	s.writeSite(plan)
+	s.writeSitemap()
+
+	s.saveCurrentConverterVersion(s.report.failedPages())
+	return nil
 }
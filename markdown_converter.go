@@ -1,20 +1,25 @@
-package main
+package notionblog
 
 import (
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/kjk/notionapi"
 	"github.com/kjk/notionapi/tomarkdown"
-	"github.com/spf13/viper"
 )
 
-var c *tomarkdown.Converter
-var lastBlock *notionapi.Block
+// converter keeps the state for converting one page to markdown
+type converter struct {
+	s         *Syncer
+	c         *tomarkdown.Converter
+	page      *Page // the page given to target
+	lastBlock *notionapi.Block
+	images    []*Image
+	err       error // first error when rendering
+}
 
-func getURLTag(pageID string, pageTitle string) (string, error) {
+func (s *Syncer) getURLTag(pageID string, pageTitle string) (string, error) {
 	pageID = notionapi.ToDashID(pageID)
 	noDashedPageID := notionapi.ToNoDashID(pageID)
 
@@ -22,25 +27,33 @@ func getURLTag(pageID string, pageTitle string) (string, error) {
 		return "", errors.New("pageID is invalid")
 	}
 
-	if _, ok := allPagesMap[pageID]; ok {
-		if _, ok := topLevelPagesMap[pageID]; ok {
-			return "{% post_link " + noDashedPageID + " %} ", nil
-		} else {
-			url := getUrlByPageID(pageID)
-			if url != "" {
-				return fmt.Sprintf("[%s](%s)", pageTitle, url), nil
-			}
+	if s.preview {
+		return fmt.Sprintf("[%s](%s)", pageTitle, previewURL(pageID)), nil
+	}
+
+	if _, ok := s.allPagesMap[pageID]; ok {
+		url := s.getUrlByPageID(pageID)
+		if _, ok := s.topLevelPagesMap[pageID]; ok || url != "" {
+			return s.target.Link(s.getPage(pageID), pageTitle, url), nil
 		}
 	}
 	return fmt.Sprintf("[%s](https://notion.so/%s)", pageTitle, noDashedPageID), nil
 
 }
 
-func rewriteURL(url string) string {
+// get the page id from the url of a notion page, or empty if it's not a notion url
+func notionPageID(url string) string {
 	if strings.HasPrefix(url, "https://notion.so/") || strings.HasPrefix(url, "https://www.notion.so/") {
 		partsBySlash := strings.Split(url, "/")
 		partsByLine := strings.Split(partsBySlash[len(partsBySlash)-1], "-")
-		pageUrl, err := getURL(partsByLine[len(partsByLine)-1])
+		return partsByLine[len(partsByLine)-1]
+	}
+	return ""
+}
+
+func (s *Syncer) rewriteURL(url string) string {
+	if pageID := notionPageID(url); pageID != "" {
+		pageUrl, err := s.getURL(pageID)
 		if err == nil {
 			url = pageUrl
 		}
@@ -48,39 +61,73 @@ func rewriteURL(url string) string {
 	return url
 }
 
+// the pages of the blog linked from page, by sub page blocks, page mentions and notion urls
+func (s *Syncer) pageLinks(page *notionapi.Page) []*PageLink {
+	var links []*PageLink
+	linked := make(map[string]bool)
+	add := func(pageID string) {
+		pageID = notionapi.ToDashID(pageID)
+		if _, ok := s.allPagesMap[pageID]; !ok || linked[pageID] {
+			return
+		}
+		linked[pageID] = true
+		links = append(links, &PageLink{
+			ID:    pageID,
+			Title: s.getPage(pageID).GetString("title"),
+			URL:   s.getUrlByPageID(pageID),
+		})
+	}
+
+	page.ForEachBlock(func(block *notionapi.Block) {
+		if block.Type == notionapi.BlockPage && !page.IsRoot(block) {
+			add(block.ID)
+		}
+		for _, span := range block.InlineContent {
+			for _, attr := range span.Attrs {
+				switch notionapi.AttrGetType(attr) {
+				case notionapi.AttrPage:
+					add(notionapi.AttrGetPageID(attr))
+				case notionapi.AttrLink:
+					if pageID := notionPageID(notionapi.AttrGetLink(attr)); pageID != "" {
+						add(pageID)
+					}
+				}
+			}
+		}
+	})
+
+	return links
+}
+
 // RenderPage renders BlockPage
-func renderPage(block *notionapi.Block) {
+func (cv *converter) renderPage(block *notionapi.Block) {
+	c := cv.c
 	if c.Page.IsRoot(block) {
 		// ignore root page's content
 		// insert front matter
-		pageID := notionapi.ToDashID(block.ID)
-
-		if db, ok := topLevelPagesMap[pageID]; ok {
-			c.WriteString(db.frontMatter.render(block))
-		} else {
-			// other pages
-			title := c.GetInlineContent(block.InlineContent, false)
-			c.Printf("title: %s\n", title)
-			c.Printf("date: %s\n", milliTimeStampToISO8601String(block.CreatedTime))
-			c.Printf("updated: %s\n", milliTimeStampToISO8601String(block.CreatedTime))
+		frontMatter, err := cv.s.target.FrontMatter(cv.page)
+		if err != nil {
+			cv.setError(fmt.Errorf("cannot render front matter: %v", err))
+			return
 		}
-
-		c.Printf("--------\n")
+		c.WriteString(frontMatter)
 		// only if the block is root, render its children
 		c.RenderChildren(block)
 		return
 	}
 
 	title := c.GetInlineContent(block.InlineContent, false)
-	pageUrl, err := getURLTag(block.ID, title)
+	pageUrl, err := cv.s.getURLTag(block.ID, title)
 	if err != nil {
-		log.Fatal("Unknown error.", err)
+		cv.setError(fmt.Errorf("cannot get url for page %s: %v", block.ID, err))
+		return
 	}
 
 	c.Printf("%s\n", pageUrl)
 }
 
-func renderText(block *notionapi.Block) {
+func (cv *converter) renderText(block *notionapi.Block) {
+	c := cv.c
 	var b strings.Builder
 	for _, block := range block.InlineContent {
 		b.WriteString(c.InlineToString(block))
@@ -89,7 +136,7 @@ func renderText(block *notionapi.Block) {
 	s := strings.TrimSpace(b.String())
 
 	if s == "{% more %}" {
-		s = "<!-- more -->"
+		s = cv.s.target.Excerpt()
 	}
 
 	c.Printf("%s\n\n", s)
@@ -97,7 +144,8 @@ func renderText(block *notionapi.Block) {
 	c.RenderChildren(block)
 }
 
-func renderCode(block *notionapi.Block) {
+func (cv *converter) renderCode(block *notionapi.Block) {
+	c := cv.c
 	code := block.Code
 	codeLanguage := trimAndToSmall(block.CodeLanguage)
 
@@ -110,17 +158,19 @@ func renderCode(block *notionapi.Block) {
 	c.Printf("```\n\n")
 }
 
-func renderCallout(block *notionapi.Block) {
+func (cv *converter) renderCallout(block *notionapi.Block) {
+	c := cv.c
 	text := c.GetInlineContent(block.InlineContent, true)
 	s := fmt.Sprintf("> %s\n", text)
 	c.WriteString(s)
 	c.Newline()
 }
 
-func renderTodo(block *notionapi.Block) {
+func (cv *converter) renderTodo(block *notionapi.Block) {
+	c := cv.c
 	text := c.GetInlineContent(block.InlineContent, true)
 
-	if viper.GetBool("render.checkbox") {
+	if cv.s.config.GetBool("render.checkbox") {
 		if block.IsChecked {
 			c.Printf("- [x]  %s\n", text)
 		} else {
@@ -139,22 +189,27 @@ func renderTodo(block *notionapi.Block) {
 	c.RenderChildren(block)
 }
 
-func renderGist(block *notionapi.Block) {
+func (cv *converter) renderGist(block *notionapi.Block) {
+	c := cv.c
 	source := block.Source
 	gistSplits := strings.Split(source, "/")
 	if len(gistSplits) >= 2 {
-		c.Printf("{%% gist %s %%}\n", gistSplits[len(gistSplits)-1])
+		c.Printf("%s\n", cv.s.target.Gist(gistSplits[len(gistSplits)-2], gistSplits[len(gistSplits)-1]))
 	} else {
 		c.Printf("Gist: %s\n", source)
-		log.Println("Invalid gist: ", source)
+		cv.s.warn("Invalid gist: ", source)
 	}
 
 	c.Newline()
 }
 
-func renderImage(block *notionapi.Block) {
+func (cv *converter) renderImage(block *notionapi.Block) {
+	c := cv.c
 	source := block.Source
-	imageUrl := downloadImage(source, block)
+	imageUrl, image := cv.s.parseImage(source, cv.page, block.ID)
+	if image != nil {
+		cv.images = append(cv.images, image)
+	}
 
 	captions := block.GetCaption() //c.InlineToString()
 	caption := ""
@@ -164,28 +219,32 @@ func renderImage(block *notionapi.Block) {
 	c.Printf("![%s](%s)\n", caption, imageUrl)
 }
 
-func render(block *notionapi.Block) bool {
-	if lastBlock != nil && lastBlock.Type != block.Type {
-		c.Newline()
+func (cv *converter) render(block *notionapi.Block) bool {
+	if cv.lastBlock != nil && cv.lastBlock.Type != block.Type {
+		cv.c.Newline()
 	}
 
-	lastBlock = block
+	cv.lastBlock = block
+
+	if renderer, ok := cv.s.target.(BlockRenderer); ok && block.Type != notionapi.BlockPage && renderer.RenderBlock(cv.c, block) {
+		return true
+	}
 
 	switch block.Type {
 	case notionapi.BlockPage:
-		renderPage(block)
+		cv.renderPage(block)
 	case notionapi.BlockText:
-		renderText(block)
+		cv.renderText(block)
 	case notionapi.BlockImage:
-		renderImage(block)
+		cv.renderImage(block)
 	case notionapi.BlockCode:
-		renderCode(block)
+		cv.renderCode(block)
 	case notionapi.BlockTodo:
-		renderTodo(block)
+		cv.renderTodo(block)
 	case notionapi.BlockGist:
-		renderGist(block)
+		cv.renderGist(block)
 	case notionapi.BlockCallout:
-		renderCallout(block)
+		cv.renderCallout(block)
 	default:
 		return false
 	}
@@ -193,13 +252,75 @@ func render(block *notionapi.Block) bool {
 	return true
 }
 
-func pageToMarkdown(page *notionapi.Page) []byte {
-	c = tomarkdown.NewConverter(page)
-	c.RenderBlockOverride = render
-	c.RewriteURL = rewriteURL
+func isInlineCode(span *notionapi.TextSpan) bool {
+	for _, attr := range span.Attrs {
+		if notionapi.AttrGetType(attr) == notionapi.AttrCode {
+			return true
+		}
+	}
+	return false
+}
+
+// escape the plain text of all blocks in page, returns the function to restore them
+// the page is shared by renders, so it must be restored after converted
+func escapeText(page *notionapi.Page, escaper TextEscaper) func() {
+	texts := make(map[*notionapi.TextSpan]string)
+	page.ForEachBlock(func(block *notionapi.Block) {
+		if block.Type == notionapi.BlockCode {
+			return
+		}
+		for _, span := range block.InlineContent {
+			if isInlineCode(span) {
+				continue
+			}
+			texts[span] = span.Text
+			span.Text = escaper.EscapeText(span.Text)
+		}
+	})
+
+	return func() {
+		for span, text := range texts {
+			span.Text = text
+		}
+	}
+}
+
+func (cv *converter) setError(err error) {
+	if cv.err == nil {
+		cv.err = err
+	}
+}
+
+// returns the markdown of page and the images used in page
+func (s *Syncer) pageToMarkdown(page *notionapi.Page) ([]byte, []*Image, error) {
+	cv := &converter{
+		s: s,
+		c: tomarkdown.NewConverter(page),
+	}
+	cv.c.RenderBlockOverride = cv.render
+	cv.c.RewriteURL = s.rewriteURL
+	cv.page = s.newPage(page.Root(), cv.c.GetInlineContent(page.Root().InlineContent, false))
 
-	lastBlock = nil
-	result := c.ToMarkdown()
-	waitDownloadImage()
-	return result
+	if escaper, ok := s.target.(TextEscaper); ok {
+		defer escapeText(page, escaper)()
+	}
+
+	result := cv.c.ToMarkdown()
+	if cv.err != nil {
+		return nil, nil, cv.err
+	}
+	cv.images = append(cv.images, cv.page.images...)
+	if output, ok := s.target.(OutputConverter); ok {
+		converted, err := output.ConvertPage(cv.page, &PageContent{
+			Markdown: result,
+			Root:     page.Root(),
+			Images:   cv.images,
+			Links:    s.pageLinks(page),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot convert page: %v", err)
+		}
+		return converted, cv.images, nil
+	}
+	return result, cv.images, nil
 }
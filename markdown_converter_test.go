@@ -1,4 +1,4 @@
-package main
+package notionblog
 
 import (
 	"github.com/magiconair/properties/assert"
@@ -6,7 +6,8 @@ import (
 )
 
 func TestGetURLL(t *testing.T) {
-	result, err := getURL("11112222aaaabbbbccccddddeeeeffff")
+	s := &Syncer{}
+	result, err := s.getURL("11112222aaaabbbbccccddddeeeeffff")
 	if err != nil {
 		t.Error(err)
 	}
@@ -14,21 +15,27 @@ func TestGetURLL(t *testing.T) {
 }
 
 func TestRewriteURL(t *testing.T) {
-	assert.Equal(t, rewriteURL("https://www.notion.so/login"), "https://www.notion.so/login")
+	s := &Syncer{}
+	assert.Equal(t, s.rewriteURL("https://www.notion.so/login"), "https://www.notion.so/login")
 	assert.Equal(t,
-		rewriteURL("https://www.notion.so/username/11112222aaaabbbbccccddddeeeeffff"),
+		s.rewriteURL("https://www.notion.so/username/11112222aaaabbbbccccddddeeeeffff"),
 		"https://notion.so/11112222aaaabbbbccccddddeeeeffff",
 	)
 	assert.Equal(t,
-		rewriteURL("https://notion.so/username/11112222aaaabbbbccccddddeeeeffff"),
+		s.rewriteURL("https://notion.so/username/11112222aaaabbbbccccddddeeeeffff"),
 		"https://notion.so/11112222aaaabbbbccccddddeeeeffff",
 	)
 	assert.Equal(t,
-		rewriteURL("https://notion.so/username/some-page-title-11112222aaaabbbbccccddddeeeeffff"),
+		s.rewriteURL("https://notion.so/username/some-page-title-11112222aaaabbbbccccddddeeeeffff"),
 		"https://notion.so/11112222aaaabbbbccccddddeeeeffff",
 	)
 	assert.Equal(t,
-		rewriteURL("https://example.com/a/b"),
+		s.rewriteURL("https://example.com/a/b"),
 		"https://example.com/a/b",
 	)
 }
+
+func TestNotionPageID(t *testing.T) {
+	assert.Equal(t, notionPageID("https://www.notion.so/username/some-page-title-11112222aaaabbbbccccddddeeeeffff"), "11112222aaaabbbbccccddddeeeeffff")
+	assert.Equal(t, notionPageID("https://example.com/a/b"), "")
+}
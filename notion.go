@@ -1,10 +1,13 @@
-package main
+package notionblog
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/kjk/notionapi"
@@ -16,226 +19,296 @@ type database struct {
 	flag             int8   // 1 for post and 2 for page
 	pageID           string // pageID for collection (Can get from url)
 	collectionID     string
-	collectionViewID string       // view for collection  (Can get from url, after "?v=")
-	subpageIDs       []string     // direct pages in the collection
-	frontMatter      *FrontMatter // front matter structure for database
+	collectionViewID string                             // view for collection  (Can get from url, after "?v=")
+	subpageIDs       []string                           // direct pages in the collection
+	allPageIDs       []string                           // direct pages in the view, including the unpublished ones
+	scheduledIDs     []string                           // published pages held back until their date
+	schema           map[string]*notionapi.ColumnSchema // columns of the collection, by column id
+	frontMatter      *FrontMatter                       // front matter structure for database
 }
 
-var notionDir string
-
-var client *notionapi.Client
-var user *notionapi.User
-var downloader *caching_downloader.Downloader
-var dbs []*database
-var tree *viper.Viper
+// the database id in config
+func (db *database) id() string {
+	return notionapi.ToNoDashID(db.pageID) + "+" + notionapi.ToNoDashID(db.collectionViewID)
+}
 
-var allPages []string
-var topLevelPages []string
-var updatedPages []string
-var allPagesMap = make(map[string]struct{}, 0)
-var topLevelPagesMap = make(map[string]*database, 0)
+// Plan describes what a sync will do to the blog
+type Plan struct {
+	// AllPages are all pages (top-level pages and their sub pages) that will be kept
+	AllPages []string
+	// RenderPages are pages whose markdown file will be (re)generated
+	RenderPages []string
+	// DeleteFiles are markdown files that will be deleted
+	DeleteFiles []string
+
+	tree      *viper.Viper      // new reference tree, will be saved to _notion/tree.yml
+	files     map[string]string // the markdown file of pages relative to the root dir, saved to tree.yml
+	siteFiles []string          // the site files generated by the last sync, relative to the root dir
+}
 
-func initClient() {
-	client = &notionapi.Client{}
-	token := viper.GetString("token_v2")
+func (s *Syncer) initClient() {
+	s.client = &notionapi.Client{}
+	token := s.config.GetString("token_v2")
 
 	if token != "" {
 		// If a token isn't passed in, the page must be public
-		client.AuthToken = token
+		s.client.AuthToken = token
 	}
 }
 
-func initUser() {
-	user = &notionapi.User{
-		Locale:   viper.GetString("user.locale"),
-		TimeZone: viper.GetString("user.timezone"),
+func (s *Syncer) initUser() {
+	s.user = &notionapi.User{
+		Locale:   s.config.GetString("user.locale"),
+		TimeZone: s.config.GetString("user.timezone"),
 	}
 
 	// TODO: use user_settings table record to get data
 }
 
-func initDownloader() {
-	cache, err := caching_downloader.NewDirectoryCache(cacheDir)
-	if err != nil {
-		log.Fatal("Cannot use source/_notion/cache dir")
+func (s *Syncer) initDownloader() error {
+	var cache caching_downloader.Cache
+	if s.dryRun {
+		cache = newDryRunCache(s.cacheDir)
+	} else {
+		dirCache, err := caching_downloader.NewDirectoryCache(s.cacheDir)
+		if err != nil {
+			return fmt.Errorf("cannot use cache dir %s: %v", s.cacheDir, err)
+		}
+		cache = dirCache
 	}
-	downloader = caching_downloader.New(cache, client)
-	downloader.RedownloadNewerVersions = true
+	s.downloader = caching_downloader.New(cache, s.client)
+	s.downloader.RedownloadNewerVersions = true
+	s.imageDownloader = caching_downloader.New(cache, nil)
+	return nil
 }
 
-func parseUserDatabases() {
-	postDatabases := viper.GetStringSlice("database.post")
+// parse database id in format pageID+ViewID
+func parseDatabaseID(id string) (*database, error) {
+	splits := strings.Split(id, "+")
+	if len(splits) != 2 {
+		return nil, errors.New("the format of database id must be pageID+ViewID")
+	}
 
-	for _, postDb := range postDatabases {
-		log.Println("Start loading data in database", postDb)
-		splits := strings.Split(postDb, "+")
-		if len(splits) != 2 {
-			log.Fatal("The format of database id must be pageID+ViewID")
-		}
+	pageID := notionapi.ToDashID(splits[0])
+	collectionViewID := notionapi.ToDashID(splits[1])
+
+	if !notionapi.IsValidDashID(pageID) || !notionapi.IsValidDashID(collectionViewID) {
+		return nil, errors.New("please check your collectionID and collectionViewID")
+	}
+
+	return &database{
+		flag:             1,
+		pageID:           pageID,
+		collectionViewID: collectionViewID,
+	}, nil
+}
 
-		pageID := notionapi.ToDashID(splits[0])
-		collectionViewID := notionapi.ToDashID(splits[1])
+func (s *Syncer) parseUserDatabases() error {
+	postDatabases := s.config.GetStringSlice("database.post")
 
-		if !notionapi.IsValidDashID(pageID) || !notionapi.IsValidDashID(collectionViewID) {
-			log.Fatal("Please check your collectionID and collectionViewID")
+	for _, postDb := range postDatabases {
+		log.Println("Start loading data in database", postDb)
+		db, err := parseDatabaseID(postDb)
+		if err != nil {
+			return err
 		}
 
-		dbs = append(dbs, &database{
-			flag:             1,
-			pageID:           pageID,
-			collectionViewID: collectionViewID,
-		})
+		s.dbs = append(s.dbs, db)
 	}
 
 	// TODO: pageDatabases
+	return nil
 }
 
-func getCollectionIDs() {
-	pageIDs := make([]string, len(dbs))
-	for i := range dbs {
-		pageIDs[i] = dbs[i].pageID
+func (s *Syncer) getCollectionIDs() error {
+	if len(s.dbs) == 0 {
+		return nil
 	}
-	resp, err := client.GetBlockRecords(pageIDs)
+
+	pageIDs := make([]string, len(s.dbs))
+	for i := range s.dbs {
+		pageIDs[i] = s.dbs[i].pageID
+	}
+	resp, err := s.client.GetBlockRecords(pageIDs)
 	if err != nil {
-		log.Fatal("Cannot get collectionID, please check if all pageID is valid.", err)
+		return fmt.Errorf("cannot get collectionID, please check if all pageID is valid: %v", err)
 	}
-	if len(resp.Results) != len(dbs) {
-		log.Fatal("Unknown error")
+	if len(resp.Results) != len(s.dbs) {
+		return errors.New("unknown error when get collectionID")
 	}
-	for i, db := range dbs {
-		block := resp.Results[i].Block
-		if block.Type != notionapi.BlockCollectionView {
-			log.Fatalf("The pageID %s is wrong, please check.", dbs[i].pageID)
+	for i, db := range s.dbs {
+		if err := checkCollectionView(db, resp.Results[i].Block); err != nil {
+			return err
 		}
-
-		db.collectionID = block.CollectionID
 		log.Printf("Found collectionID (%s) for page %s.", db.collectionID, db.pageID)
+	}
+	return nil
+}
 
-		found := false
-		for _, viewId := range block.ViewIDs {
-			if db.collectionViewID == viewId {
-				found = true
-				break
-			}
+// check if the block is the collection view of db, db.collectionID will be assigned
+func checkCollectionView(db *database, block *notionapi.Block) error {
+	if block == nil || block.Type != notionapi.BlockCollectionView {
+		return fmt.Errorf("the pageID %s is wrong, please check", db.pageID)
+	}
+
+	db.collectionID = block.CollectionID
+
+	for _, viewId := range block.ViewIDs {
+		if db.collectionViewID == viewId {
+			return nil
 		}
-		if !found {
-			log.Fatalf("The viewID %s cannot be found, please check.\n", db.collectionViewID)
+	}
+	return fmt.Errorf("the viewID %s cannot be found, please check", db.collectionViewID)
+}
+
+// query the collection view of db, returns the response and the collection
+func (s *Syncer) queryDatabase(db *database) (*notionapi.QueryCollectionResponse, *notionapi.Collection, error) {
+	resp, err := s.client.QueryCollection(db.collectionID, db.collectionViewID, json.RawMessage("{}"), s.user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not read data from view: %v", err)
+	}
+
+	collection := resp.RecordMap.Collections[db.collectionID]
+	if collection == nil || collection.Collection == nil {
+		return nil, nil, errors.New("can not read collection data from view")
+	}
+	return resp, collection.Collection, nil
+}
+
+func (s *Syncer) buildFrontMatter(columns map[string]*notionapi.ColumnSchema) (*FrontMatter, error) {
+	m := make(idToNameMap, len(columns))
+	for id, schema := range columns {
+		m[id] = &idToNameStructure{
+			Name:   trimAndConvertSpace(schema.Name),
+			Type:   schema.Type,
+			Schema: schema,
 		}
 	}
+	return newFrontMatter(s, m)
 }
 
 // topLevelPages, topLevelPagesMap will be assigned
-func fetchDatabaseInfo() {
-	for _, db := range dbs {
+func (s *Syncer) fetchDatabaseInfo() error {
+	for _, db := range s.dbs {
 		log.Println("Fetch data for database ", db.pageID)
+		s.report.add(&s.report.Databases, db.id())
 
 		// Get basic data for the collection
-		resp, err := client.QueryCollection(db.collectionID, db.collectionViewID, json.RawMessage("{}"), user)
+		resp, collection, err := s.queryDatabase(db)
 		if err != nil {
-			log.Fatal("Can not read data from view.", err)
+			return err
 		}
 
-		// Read collection Basic Data
-		collection := resp.RecordMap.Collections[db.collectionID]
-		if collection == nil {
-			log.Fatal("Can not read collection data from view.", err)
-		}
-
-		// Build front matter
-		m := make(idToNameMap, len(collection.Collection.Schema))
-		for id, schema := range collection.Collection.Schema {
-			m[id] = &idToNameStructure{
-				Name: trimAndConvertSpace(schema.Name),
-				Type: schema.Type,
+		// Build front matter, only once for a connection
+		if db.frontMatter == nil {
+			db.schema = collection.Schema
+			db.frontMatter, err = s.buildFrontMatter(db.schema)
+			if err != nil {
+				return fmt.Errorf("invalid schema of database %s: %v", db.pageID, err)
 			}
 		}
-		db.frontMatter = newFrontMatter(m)
 
 		// Get pages in the collection directly
 		for _, id := range resp.Result.BlockIDS {
-			topLevelPagesMap[id] = db
+			s.topLevelPagesMap[id] = db
 		}
 		db.subpageIDs = resp.Result.BlockIDS
-		topLevelPages = append(topLevelPages, resp.Result.BlockIDS...)
+		db.allPageIDs = resp.Result.BlockIDS
+		s.topLevelPages = append(s.topLevelPages, resp.Result.BlockIDS...)
 	}
+	return nil
 }
 
 // updatedPages will be assigned
-func downloadAllPages() {
-	for _, db := range dbs {
+func (s *Syncer) downloadAllPages() error {
+	for _, db := range s.dbs {
 		log.Println("Download pages for db", db.collectionViewID)
-		partUpdatedPages, err := downloadPagesAndSubPagesOnDemand(downloader, db.subpageIDs)
+		partUpdatedPages, err := s.downloadPagesAndSubPagesOnDemand(db.subpageIDs)
 		if err != nil {
-			log.Fatal("Fail to download pages in db ", db.collectionViewID, ": ", err)
+			return fmt.Errorf("fail to download pages in db %s: %v", db.collectionViewID, err)
 		}
 		log.Println(len(partUpdatedPages), "pages updated.")
-		updatedPages = append(updatedPages, partUpdatedPages...)
+		s.report.add(&s.report.DownloadedPages, partUpdatedPages...)
+		s.updatedPages = append(s.updatedPages, partUpdatedPages...)
 	}
+	return nil
 }
 
-// topLevelPages, topLevelPagesMap, updatedPages, db.subpageIDs will be modified
-func filterPublishedPages() {
-	newTopLevelPages := make([]string, 0, len(topLevelPages))
-	newTopLevelPagesMap := make(map[string]*database, len(topLevelPagesMap))
+// topLevelPages, topLevelPagesMap, updatedPages, db.subpageIDs, db.scheduledIDs will be modified
+func (s *Syncer) filterPublishedPages() error {
+	newTopLevelPages := make([]string, 0, len(s.topLevelPages))
+	newTopLevelPagesMap := make(map[string]*database, len(s.topLevelPagesMap))
 
-	newUpdatedPages := make([]string, 0, len(updatedPages))
-	updatedPagesMap := make(map[string]struct{}, len(updatedPages))
-	for _, p := range updatedPages {
+	newUpdatedPages := make([]string, 0, len(s.updatedPages))
+	updatedPagesMap := make(map[string]struct{}, len(s.updatedPages))
+	for _, p := range s.updatedPages {
 		updatedPagesMap[p] = struct{}{}
 	}
 
-	for _, db := range dbs {
+	for _, db := range s.dbs {
 		newSubpageIDs := make([]string, 0, len(db.subpageIDs))
+		db.scheduledIDs = nil
 		for _, pageID := range db.subpageIDs {
 
-			page, err := downloader.ReadPageFromCache(pageID)
+			page, err := s.readCachedPage(pageID)
 			if err != nil {
-				log.Fatal("Unknown error when read page from cache. ", err)
+				return err
+			}
+			if page == nil {
+				// fail to download and never cached, so no file for it
+				continue
+			}
+			published, scheduled := s.checkIfPublished(page.Root(), db.frontMatter)
+			if scheduled {
+				db.scheduledIDs = append(db.scheduledIDs, pageID)
 			}
-			checkResult := checkIfPublished(page, db.frontMatter)
-			if checkResult {
+			if published {
 				newSubpageIDs = append(newSubpageIDs, pageID)
 				newTopLevelPages = append(newTopLevelPages, pageID)
 				newTopLevelPagesMap[pageID] = db
 				if _, ok := updatedPagesMap[pageID]; ok {
 					newUpdatedPages = append(newUpdatedPages, pageID)
 				}
+			} else {
+				s.report.add(&s.report.UnpublishedPages, pageID)
 			}
 		}
 		db.subpageIDs = newSubpageIDs
 	}
 
-	topLevelPages = newTopLevelPages
-	updatedPages = newUpdatedPages
-	topLevelPagesMap = newTopLevelPagesMap
+	s.topLevelPages = newTopLevelPages
+	s.updatedPages = newUpdatedPages
+	s.topLevelPagesMap = newTopLevelPagesMap
+	return nil
 }
 
 // parse existed reference tree, generate new reference tree
-// delete unused page cache, mark need to delete page
-// allPages, allPagesMap will be assigned
-func handleTree() {
-	treeFilename := path.Join(notionDir, "tree.yml")
+// mark need to delete page
+// allPages, allPagesMap will be assigned, plan.tree and plan.DeleteFiles will be filled
+func (s *Syncer) handleTree(plan *Plan) error {
+	treeFilename := path.Join(s.notionDir, "tree.yml")
 
 	oldTree := viper.New()
 	oldTree.SetConfigFile(treeFilename)
 
-	tree = viper.New()
+	tree := viper.New()
 
 	if err := oldTree.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("The source/_notion/tree.yml file is not exist, create one.")
+			log.Println("The _notion/tree.yml file is not exist, create one.")
 		} else {
 			//oldTree.Set("enable", false)
 			log.Println("Cannot open the old tree file, the old-tree-based function will not be work.", err)
 		}
 	}
 
-	tree.Set("top", topLevelPages)
+	tree.Set("top", s.topLevelPages)
+	tree.Set("databases", s.getTreeDatabases())
 
 	// find need delete files
 	oldTopPages := oldTree.GetStringSlice("top")
 
-	toDeleteTopPages := findInBButNotInA(topLevelPages, oldTopPages)
+	toDeleteTopPages := findInBButNotInA(s.topLevelPages, oldTopPages)
 	toDeleteSubPages := make(map[string]struct{}, 0)
 
 	for _, page := range oldTopPages {
@@ -246,15 +319,20 @@ func handleTree() {
 		}
 	}
 
-	for _, page := range topLevelPages {
-		allPages = append(allPages, page)
-		allPagesMap[page] = struct{}{}
+	s.allPages = make([]string, 0, len(s.topLevelPages))
+	s.allPagesMap = make(map[string]struct{}, len(s.topLevelPages))
+	for _, page := range s.topLevelPages {
+		s.allPages = append(s.allPages, page)
+		s.allPagesMap[page] = struct{}{}
 
-		existedSubPages := getAllSubPagesFromCacheRecursion(page)
-		allPages = append(allPages, existedSubPages...)
+		existedSubPages, err := s.getAllSubPagesFromCacheRecursion(page)
+		if err != nil {
+			return err
+		}
+		s.allPages = append(s.allPages, existedSubPages...)
 
 		for _, pageID := range existedSubPages {
-			allPagesMap[pageID] = struct{}{}
+			s.allPagesMap[pageID] = struct{}{}
 
 			delete(toDeleteSubPages, pageID)
 		}
@@ -264,91 +342,330 @@ func handleTree() {
 
 	// top page -> sub page
 	for _, page := range toDeleteTopPages {
-		if _, ok := allPagesMap[page]; ok {
-			updatedPages = append(updatedPages, page)
+		if _, ok := s.allPagesMap[page]; ok {
+			s.updatedPages = append(s.updatedPages, page)
 		}
 	}
-
-	// save new tree
-	err := tree.WriteConfigAs(treeFilename)
-	if err != nil {
-		log.Println("Warning: Cannot write tree to file.", err)
+	// newly published pages need to be rendered even if they are not updated
+	if len(oldTopPages) != 0 {
+		s.updatedPages = append(s.updatedPages, findInBButNotInA(oldTopPages, s.topLevelPages)...)
+	}
+	// so do the scheduled pages which are due now, whatever the old tree contains
+	for _, pageID := range oldScheduledPages(oldTree) {
+		if _, ok := s.topLevelPagesMap[pageID]; ok {
+			s.updatedPages = append(s.updatedPages, pageID)
+		}
+	}
+	// and the pages whose position is changed by inserted, removed or reordered pages
+	positions := oldPositions(oldTree)
+	for _, db := range s.dbs {
+		for i, pageID := range db.subpageIDs {
+			if position, ok := positions[pageID]; ok && position != i+1 {
+				s.updatedPages = append(s.updatedPages, pageID)
+			}
+		}
+	}
+	s.updatedPages = unique(s.updatedPages)
+
+	plan.tree = tree
+	// kept until the site is generated again
+	plan.siteFiles = oldTree.GetStringSlice("site_files")
+	tree.Set("site_files", plan.siteFiles)
+
+	// the filename may depend on front matter, so the files of last sync are used if they are known
+	oldFiles := oldTree.GetStringMapString("files")
+	plan.files = make(map[string]string, len(s.allPages))
+	for _, pageID := range s.allPages {
+		if filename, ok := oldFiles[pageID]; ok {
+			plan.files[pageID] = filename
+		}
+	}
+	oldSavePath := func(page *Page) string {
+		if filename, ok := oldFiles[page.ID]; ok {
+			return s.recordedPath(filename)
+		}
+		// a bundle dir is only deleted if it's recorded as the dir of page
+		return s.getSavePath(page)
 	}
 
-	// delete need delete top-level pages
+	// need delete top-level pages
 	for _, pageID := range toDeleteTopPages {
-		filename := path.Join(postsDir, getFilename(pageID))
-		log.Println("Will delete markdown file:", filename)
-		_ = os.Remove(filename)
+		plan.DeleteFiles = append(plan.DeleteFiles, oldSavePath(&Page{ID: pageID, Post: true}))
 	}
-	// delete need delete sub pages
+	// need delete sub pages
 	for pageID := range toDeleteSubPages {
-		filename := path.Join(pagesDir, getFilename(pageID))
+		plan.DeleteFiles = append(plan.DeleteFiles, oldSavePath(&Page{ID: pageID}))
+	}
+
+	return nil
+}
+
+// rerender the posts whose file is moved without being edited, e.g. their slug is used by another post now
+func (s *Syncer) handleMovedPosts(plan *Plan) error {
+	for _, pageID := range s.topLevelPages {
+		filename, ok := plan.files[pageID]
+		if !ok {
+			continue
+		}
+		page, err := s.readCachedPage(pageID)
+		if err != nil {
+			return err
+		}
+		if page == nil {
+			continue
+		}
+		if s.recordedPath(filename) != s.getRecordPath(s.newPage(page.Root(), page.Root().Title)) {
+			s.updatedPages = append(s.updatedPages, pageID)
+		}
+	}
+	s.updatedPages = unique(s.updatedPages)
+	return nil
+}
+
+// record the file of rendered page in plan, the file of last sync will be deleted if it's moved
+// filename is a dir if it ends with a slash
+func (s *Syncer) recordFile(plan *Plan, pageID string, filename string) {
+	relFilename, err := filepath.Rel(s.rootDir, filename)
+	if err != nil {
+		return
+	}
+	relFilename = filepath.ToSlash(relFilename)
+	if strings.HasSuffix(filename, "/") {
+		relFilename += "/"
+	}
+
+	if plan.files == nil {
+		plan.files = make(map[string]string)
+	}
+	if oldFilename, ok := plan.files[pageID]; ok && oldFilename != relFilename {
+		plan.DeleteFiles = append(plan.DeleteFiles, s.recordedPath(oldFilename))
+	}
+	plan.files[pageID] = relFilename
+}
+
+// save new tree and delete files marked by handleTree
+func (s *Syncer) applyTree(plan *Plan) {
+	s.saveTree(plan)
+
+	// a file is never deleted if it's recorded as the file of a kept page
+	owned := make(map[string]struct{}, len(plan.files))
+	for _, filename := range plan.files {
+		owned[s.recordedPath(filename)] = struct{}{}
+	}
+
+	for _, filename := range plan.DeleteFiles {
+		if _, ok := owned[filename]; ok {
+			continue
+		}
 		log.Println("Will delete markdown file:", filename)
-		_ = os.Remove(filename)
+		if strings.HasSuffix(filename, "/") {
+			// the dir of page bundle, with its images
+			if !s.isBundleDir(filename) || !fileExists(filename) {
+				continue
+			}
+			if err := os.RemoveAll(filename); err == nil {
+				s.report.add(&s.report.DeletedFiles, filename)
+			}
+		} else if err := os.Remove(filename); err == nil {
+			s.report.add(&s.report.DeletedFiles, filename)
+		}
+	}
+}
+
+func (s *Syncer) saveTree(plan *Plan) {
+	treeFilename := path.Join(s.notionDir, "tree.yml")
+	plan.tree.Set("files", plan.files)
+	if len(s.users) != 0 {
+		// the names of users are kept for the offline mode
+		plan.tree.Set("users", s.users)
+	}
+	err := plan.tree.WriteConfigAs(treeFilename)
+	if err != nil {
+		s.warn("Warning: Cannot write tree to file.", err)
+	}
+}
+
+// the absolute path of a file recorded in tree.yml, keeps the slash of dir
+func (s *Syncer) recordedPath(filename string) string {
+	if strings.HasSuffix(filename, "/") {
+		return path.Join(s.rootDir, filename) + "/"
+	}
+	return path.Join(s.rootDir, filename)
+}
+
+// only a dir inside the posts or pages dir is deleted as a page bundle, never the posts or pages dir itself
+func (s *Syncer) isBundleDir(dir string) bool {
+	dir = path.Clean(dir)
+	for _, parent := range []string{s.layout.PostsDir, s.layout.PagesDir} {
+		if rel, err := filepath.Rel(parent, dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// read page from memory or cache dir, returns nil if the page is not cached
+func (s *Syncer) readCachedPage(pageID string) (*notionapi.Page, error) {
+	pageID = notionapi.ToDashID(pageID)
+	if page, ok := s.pages[pageID]; ok {
+		return page, nil
+	}
+
+	page, err := s.downloader.ReadPageFromCache(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil {
+		s.pages[pageID] = page
+	}
+	return page, nil
+}
+
+func (s *Syncer) readPageFromCache(pageID string) (*notionapi.Page, error) {
+	page, err := s.readCachedPage(pageID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read page %s from cache: %v", pageID, err)
+	}
+	if page == nil {
+		return nil, fmt.Errorf("cannot find page %s in cache", pageID)
 	}
+	return page, nil
 }
 
-func getAllSubPagesFromCacheRecursion(pageID string) []string {
+func (s *Syncer) getAllSubPagesFromCacheRecursion(pageID string) ([]string, error) {
 	subPages := make([]string, 0)
 	queue := make([]string, 0)
 	seen := make(map[string]struct{}, 0)
 
-	rootPage, err := downloader.ReadPageFromCache(pageID)
+	rootPage, err := s.readPageFromCache(pageID)
 	if err != nil {
-		log.Fatal("Unknown cache error.", err)
+		return nil, err
 	}
 	queue = append(queue, rootPage.ID)
 	seen[rootPage.ID] = struct{}{}
 	for len(queue) != 0 {
 		pageID := queue[0]
-		page, err := downloader.ReadPageFromCache(pageID)
+		page, err := s.readCachedPage(pageID)
 		if err != nil {
-			log.Fatal("Unknown cache error.", err)
+			return nil, err
 		}
+		queue = queue[1:]
+		if page == nil {
+			// fail to download and never cached
+			continue
+		}
+		if pageID != rootPage.ID {
+			subPages = append(subPages, pageID)
+		}
+
 		subPageIDs := page.GetSubPages()
 		for _, subPageID := range subPageIDs {
 			if _, ok := seen[subPageID]; !ok {
 				// The page is not in queue
 				seen[subPageID] = struct{}{}
 				queue = append(queue, subPageID)
-				subPages = append(subPages, subPageID)
 			}
 		}
-
-		queue = queue[1:]
 	}
 
-	return subPages
+	return subPages, nil
 }
 
-func clearCache() {
-	allPageIds, err := downloader.Cache.GetPageIDs()
-	toDashIDs(allPageIds)
+// delete unused page cache
+func (s *Syncer) clearCache() error {
+	allPageIds, err := s.downloader.Cache.GetPageIDs()
 	if err != nil {
-		log.Fatal("Cannot get all pageIDs from cache.")
+		return fmt.Errorf("cannot get all pageIDs from cache: %v", err)
 	}
+	toDashIDs(allPageIds)
 
-	toDeletePageIds := findInBButNotInA(allPages, allPageIds)
+	// the scheduled pages are kept, so they can be published in offline mode once they are due
+	keepPageIds := append([]string{}, s.allPages...)
+	for _, db := range s.dbs {
+		for _, pageID := range db.scheduledIDs {
+			subPages, err := s.getAllSubPagesFromCacheRecursion(pageID)
+			if err != nil {
+				return err
+			}
+			keepPageIds = append(keepPageIds, pageID)
+			keepPageIds = append(keepPageIds, subPages...)
+		}
+	}
+	toDeletePageIds := findInBButNotInA(keepPageIds, allPageIds)
 
 	for _, id := range toDeletePageIds {
-		cacheFileName := downloader.NameForPageID(id)
+		cacheFileName := s.downloader.NameForPageID(id)
 		log.Println("Delete Cache:", cacheFileName)
-		downloader.Cache.Remove(cacheFileName)
+		s.downloader.Cache.Remove(cacheFileName)
+		delete(s.pages, id)
+	}
+	return nil
+}
+
+// init client and downloader, find the databases in config
+func (s *Syncer) connect() error {
+	s.dbs = nil
+	s.pages = make(map[string]*notionapi.Page)
+
+	s.initClient()
+	s.initUser()
+	if err := s.initDownloader(); err != nil {
+		return err
+	}
+
+	if err := s.parseUserDatabases(); err != nil {
+		return err
 	}
+	return s.getCollectionIDs()
 }
 
-// The function is used to generate helper data
-func generateBaseData() {
-	initClient()
-	initUser()
-	initDownloader()
-
-	parseUserDatabases()
-	getCollectionIDs()
-	fetchDatabaseInfo()
-	downloadAllPages()
-	filterPublishedPages()
-	handleTree()
-	clearCache()
+// query the databases and download updated pages, the connection and the front matter of databases will be reused
+func (s *Syncer) fetch() error {
+	s.topLevelPages = nil
+	s.topLevelPagesMap = make(map[string]*database)
+	s.updatedPages = nil
+	s.users = make(map[string]string)
+	s.blocks = make(map[string]*notionapi.Block)
+
+	if err := s.timePhase("query", s.fetchDatabaseInfo); err != nil {
+		return err
+	}
+	if err := s.timePhase("download", s.downloadAllPages); err != nil {
+		return err
+	}
+	return s.timePhase("filter", s.filterPublishedPages)
+}
+
+// Fetch reads all databases in config and downloads the updated pages into cache
+// In offline mode, the databases are read from tree.yml and the pages are read from cache
+func (s *Syncer) Fetch() error {
+	s.resetReport()
+
+	if s.offline {
+		return s.fetchOffline()
+	}
+
+	if err := s.timePhase("connect", s.connect); err != nil {
+		return err
+	}
+	return s.fetch()
+}
+
+// Plan computes which pages need to be rendered and which files need to be deleted, nothing will be written
+func (s *Syncer) Plan() (*Plan, error) {
+	plan := &Plan{}
+
+	if err := s.timePhase("tree", func() error { return s.handleTree(plan) }); err != nil {
+		return nil, err
+	}
+	if err := s.timePhase("url_map", s.generateUrlMap); err != nil {
+		return nil, err
+	}
+	if err := s.handleMovedPosts(plan); err != nil {
+		return nil, err
+	}
+
+	plan.AllPages = s.allPages
+	plan.RenderPages = s.getReRenderedPages()
+	return plan, nil
 }
@@ -0,0 +1,221 @@
+package notionblog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+)
+
+// treeColumn is a column of database saved in tree.yml
+type treeColumn struct {
+	ID   string `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"`
+
+	// used to compute rollups and formulas
+	Aggregation      string `mapstructure:"aggregation"`
+	RelationProperty string `mapstructure:"relation_property"`
+	TargetProperty   string `mapstructure:"target_property"`
+	Formula          string `mapstructure:"formula"` // in json
+}
+
+// treeDatabase is a database saved in tree.yml, which is enough to render the pages without notion
+type treeDatabase struct {
+	ID     string        `mapstructure:"id"`
+	Schema []*treeColumn `mapstructure:"schema"`
+	Pages  []string      `mapstructure:"pages"`
+	// AllPages are all pages in the view, including the drafts
+	AllPages []string `mapstructure:"all_pages"`
+	// Scheduled are the published pages held back until their date
+	Scheduled []string `mapstructure:"scheduled"`
+}
+
+// returns the databases saved to tree.yml
+// the columns are saved as list, because the keys of map will be lowercased by viper
+func (s *Syncer) getTreeDatabases() []map[string]interface{} {
+	databases := make([]map[string]interface{}, 0, len(s.dbs))
+	for _, db := range s.dbs {
+		ids := make([]string, 0, len(db.schema))
+		for id := range db.schema {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids) // keep tree.yml stable
+
+		schema := make([]map[string]interface{}, 0, len(db.schema))
+		for _, id := range ids {
+			column := db.schema[id]
+			treeColumn := map[string]interface{}{
+				"id":   id,
+				"name": column.Name,
+				"type": column.Type,
+			}
+			if column.Type == notionapi.ColumnTypeRollup {
+				treeColumn["aggregation"] = column.Aggregation
+				treeColumn["relation_property"] = column.RelationProperty
+				treeColumn["target_property"] = column.TargetProperty
+			}
+			if column.Formula != nil {
+				if formula, err := json.Marshal(column.Formula); err == nil {
+					treeColumn["formula"] = string(formula)
+				}
+			}
+			schema = append(schema, treeColumn)
+		}
+
+		databases = append(databases, map[string]interface{}{
+			"id":        db.id(),
+			"schema":    schema,
+			"pages":     db.subpageIDs,
+			"all_pages": db.allPageIDs,
+			"scheduled": db.scheduledIDs,
+		})
+	}
+	return databases
+}
+
+// fetchOffline reads the databases from tree.yml instead of notion
+// topLevelPages, topLevelPagesMap will be assigned
+func (s *Syncer) fetchOffline() error {
+	s.dbs = nil
+	s.pages = make(map[string]*notionapi.Page)
+	s.topLevelPages = nil
+	s.topLevelPagesMap = make(map[string]*database)
+	s.updatedPages = nil
+
+	// the client is only used to read pages from cache
+	s.initClient()
+	s.initUser()
+	if err := s.initDownloader(); err != nil {
+		return err
+	}
+
+	if _, err := s.loadTree(); err != nil {
+		return fmt.Errorf("offline mode needs the _notion/tree.yml of the last sync: %v", err)
+	}
+
+	// the scheduled pages are checked again, they are published once they are due
+	s.topLevelPages = nil
+	for _, db := range s.dbs {
+		db.subpageIDs = offlineCandidates(db)
+		s.topLevelPages = append(s.topLevelPages, db.subpageIDs...)
+	}
+
+	return s.timePhase("filter", s.filterPublishedPages)
+}
+
+// the published and scheduled pages of db, in the order of view
+func offlineCandidates(db *database) []string {
+	candidates := make(map[string]struct{}, len(db.subpageIDs)+len(db.scheduledIDs))
+	for _, pageID := range db.subpageIDs {
+		candidates[pageID] = struct{}{}
+	}
+	for _, pageID := range db.scheduledIDs {
+		candidates[pageID] = struct{}{}
+	}
+
+	pageIDs := make([]string, 0, len(candidates))
+	for _, pageID := range db.allPageIDs {
+		if _, ok := candidates[pageID]; ok {
+			pageIDs = append(pageIDs, pageID)
+		}
+	}
+	return pageIDs
+}
+
+// the scheduled pages of all databases in the old tree
+func oldScheduledPages(oldTree *viper.Viper) []string {
+	var databases []*treeDatabase
+	if err := oldTree.UnmarshalKey("databases", &databases); err != nil {
+		return nil
+	}
+	var pageIDs []string
+	for _, db := range databases {
+		pageIDs = append(pageIDs, db.Scheduled...)
+	}
+	return pageIDs
+}
+
+// the positions of pages in the old tree, which are the orders of the published pages of databases
+func oldPositions(oldTree *viper.Viper) map[string]int {
+	var databases []*treeDatabase
+	if err := oldTree.UnmarshalKey("databases", &databases); err != nil {
+		return nil
+	}
+	positions := make(map[string]int)
+	for _, db := range databases {
+		for i, pageID := range db.Pages {
+			positions[pageID] = i + 1
+		}
+	}
+	return positions
+}
+
+// loadTree reads the databases and their pages from tree.yml, returns the tree
+// dbs, topLevelPages, topLevelPagesMap will be assigned
+func (s *Syncer) loadTree() (*viper.Viper, error) {
+	tree := viper.New()
+	tree.SetConfigFile(path.Join(s.notionDir, "tree.yml"))
+	if err := tree.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var databases []*treeDatabase
+	if err := tree.UnmarshalKey("databases", &databases); err != nil {
+		return nil, fmt.Errorf("cannot read databases from tree.yml: %v", err)
+	}
+	if len(databases) == 0 {
+		return nil, errors.New("no database in tree.yml, please sync online once")
+	}
+
+	for _, treeDb := range databases {
+		log.Println("Load database", treeDb.ID, "from tree")
+		s.report.add(&s.report.Databases, treeDb.ID)
+		db, err := parseDatabaseID(treeDb.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		db.schema = make(map[string]*notionapi.ColumnSchema, len(treeDb.Schema))
+		for _, column := range treeDb.Schema {
+			db.schema[column.ID] = &notionapi.ColumnSchema{
+				Name:             column.Name,
+				Type:             column.Type,
+				Aggregation:      column.Aggregation,
+				RelationProperty: column.RelationProperty,
+				TargetProperty:   column.TargetProperty,
+			}
+			if column.Formula != "" {
+				var formula notionapi.ColumnFormula
+				if err := json.Unmarshal([]byte(column.Formula), &formula); err == nil {
+					db.schema[column.ID].Formula = &formula
+				}
+			}
+		}
+		db.frontMatter, err = s.buildFrontMatter(db.schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema of database %s: %v", db.pageID, err)
+		}
+
+		for _, id := range treeDb.Pages {
+			s.topLevelPagesMap[id] = db
+		}
+		db.subpageIDs = treeDb.Pages
+		db.allPageIDs = treeDb.AllPages
+		db.scheduledIDs = treeDb.Scheduled
+		if len(db.allPageIDs) == 0 {
+			// tree.yml of old versions
+			db.allPageIDs = treeDb.Pages
+		}
+		s.topLevelPages = append(s.topLevelPages, treeDb.Pages...)
+		s.dbs = append(s.dbs, db)
+	}
+	s.users = tree.GetStringMapString("users")
+
+	return tree, nil
+}
@@ -0,0 +1,243 @@
+package notionblog
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kjk/notionapi"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v2"
+)
+
+const previewPrefix = "/page/"
+
+// the url of page in preview server
+func previewURL(pageID string) string {
+	return previewPrefix + notionapi.ToNoDashID(pageID)
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 800px; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.6; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+img { max-width: 100%; }
+blockquote { border-left: 4px solid #ddd; margin-left: 0; padding-left: 1em; color: #555; }
+table.front-matter { font-size: 0.9em; color: #555; border-collapse: collapse; margin-bottom: 2em; }
+table.front-matter td { border: 1px solid #eee; padding: 0.2em 0.6em; }
+</style>
+</head>
+<body>
+<p><a href="/">Index</a></p>
+{{if .FrontMatter}}<table class="front-matter">
+{{range .FrontMatter}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Title}}<h1>{{.Title}}</h1>{{end}}
+{{.Content}}
+</body>
+</html>
+`))
+
+var previewIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>NB Preview</title>
+<style>body { max-width: 800px; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.6; }</style>
+</head>
+<body>
+<h1>NB Preview</h1>
+<form action="/page/" method="get">
+<input name="url" size="60" placeholder="Page id or notion url, drafts work too">
+<button type="submit">Preview</button>
+</form>
+<ul>
+{{range .}}<li><a href="{{.URL}}">{{.Title}}</a>{{if .Draft}} (draft){{end}}</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+type previewLink struct {
+	URL   string
+	Title string
+	Draft bool // the page is in a database but not published
+}
+
+type previewPage struct {
+	Title       string
+	FrontMatter [][2]string
+	Content     template.HTML
+}
+
+// previewTarget renders the front matter, excerpt and embeds of the target in a way the preview server understands
+type previewTarget struct {
+	Target
+}
+
+func (previewTarget) FrontMatter(page *Page) (string, error) {
+	return hexoTarget{}.FrontMatter(page)
+}
+
+// the images are always served at /images by the preview server
+func (t previewTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	filename, _ := t.Target.Image(layout, page, name)
+	return filename, "/images" + name
+}
+
+func (previewTarget) Excerpt() string {
+	return "<!-- more -->"
+}
+
+func (previewTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("<script src=\"https://gist.github.com/%s/%s.js\"></script>", user, id)
+}
+
+// previewServer renders notion pages to html on request
+type previewServer struct {
+	s        *Syncer
+	markdown goldmark.Markdown
+
+	mu     sync.Mutex        // the Syncer can only render one page at a time
+	images map[string]*Image // images found when rendering, by url
+}
+
+// PreviewHandler returns a http handler which renders pages to html on request.
+// Any page (cached, live-fetched, published or not) can be visited at /page/<id>, and the images used by the rendered pages are served at /images.
+// Images not downloaded yet are fetched from notion without being saved.
+func (s *Syncer) PreviewHandler() (http.Handler, error) {
+	s.resetReport()
+	s.preview = true
+	s.target = previewTarget{s.target}
+	if err := s.prepareRenderPage(); err != nil {
+		return nil, err
+	}
+
+	server := &previewServer{
+		s: s,
+		markdown: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithRendererOptions(html.WithUnsafe()),
+		),
+		images: make(map[string]*Image),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", server.serveIndex)
+	mux.HandleFunc(previewPrefix, server.servePage)
+	mux.HandleFunc("/images/", server.serveImage)
+	return mux, nil
+}
+
+func (p *previewServer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	p.mu.Lock()
+	var links []*previewLink
+	for _, db := range p.s.dbs {
+		for _, pageID := range db.allPageIDs {
+			title := pageID
+			if page, err := p.s.readCachedPage(pageID); err == nil && page != nil && page.Root().Title != "" {
+				title = page.Root().Title
+			}
+			_, published := p.s.allPagesMap[pageID]
+			links = append(links, &previewLink{URL: previewURL(pageID), Title: title, Draft: !published})
+		}
+	}
+	p.mu.Unlock()
+
+	if err := previewIndexTemplate.Execute(w, links); err != nil {
+		log.Println("Warning: cannot render index.", err)
+	}
+}
+
+func (p *previewServer) servePage(w http.ResponseWriter, r *http.Request) {
+	pageID := strings.TrimPrefix(r.URL.Path, previewPrefix)
+	if pageID == "" {
+		pageID = r.URL.Query().Get("url")
+	}
+
+	p.mu.Lock()
+	data, images, err := p.s.renderSinglePage(pageID)
+	for _, image := range images {
+		p.images[image.URL] = image
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	page := &previewPage{}
+	body := data
+	// the yaml front matter is between the lines of dashes
+	if bytes.HasPrefix(data, []byte("---\n")) {
+		if i := bytes.Index(data[len("---\n"):], []byte("\n---\n")); i >= 0 {
+			end := len("---\n") + i
+			var fields yaml.MapSlice
+			if err := yaml.Unmarshal(data[len("---\n"):end], &fields); err == nil {
+				for _, field := range fields {
+					name, value := fmt.Sprint(field.Key), fmt.Sprint(field.Value)
+					if name == "title" {
+						page.Title = value
+					}
+					page.FrontMatter = append(page.FrontMatter, [2]string{name, value})
+				}
+			}
+			body = data[end+len("\n---\n"):]
+		}
+	}
+
+	var b bytes.Buffer
+	if err := p.markdown.Convert(body, &b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page.Content = template.HTML(b.String())
+
+	if err := previewTemplate.Execute(w, page); err != nil {
+		log.Println("Warning: cannot render page.", err)
+	}
+}
+
+func (p *previewServer) serveImage(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	image, ok := p.images[r.URL.Path]
+	p.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if fileExists(image.Filename) {
+		http.ServeFile(w, r, image.Filename)
+		return
+	}
+	if p.s.offline {
+		http.NotFound(w, r)
+		return
+	}
+
+	resp, err := p.s.fetchImage(image)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	_, _ = io.Copy(w, resp.Body)
+}
@@ -0,0 +1,218 @@
+package notionblog
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/kjk/notionapi"
+)
+
+// the plain text of property, the mentions of users, pages and dates are left out
+func propertyText(property interface{}) string {
+	spans, err := notionapi.ParseTextSpans(property)
+	if err != nil {
+		return ""
+	}
+	return notionapi.TextSpansToString(spans)
+}
+
+// the values of the attrs of type typ in property, e.g. the user ids of a person property
+func propertyAttrs(property interface{}, typ string) []string {
+	spans, _ := notionapi.ParseTextSpans(property)
+	var values []string
+	for _, span := range spans {
+		for _, attr := range span.Attrs {
+			if notionapi.AttrGetType(attr) == typ && len(attr) > 1 {
+				values = append(values, attr[1])
+			}
+		}
+	}
+	return values
+}
+
+// the time zone of a date property, empty if it's not given
+func propertyTimeZone(property interface{}) string {
+	spans, _ := notionapi.ParseTextSpans(property)
+	for _, span := range spans {
+		for _, attr := range span.Attrs {
+			if notionapi.AttrGetType(attr) == notionapi.AttrDate && len(attr) > 1 {
+				var date notionapi.Date
+				if err := json.Unmarshal([]byte(attr[1]), &date); err == nil && date.TimeZone != nil {
+					return *date.TimeZone
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// a single value is given as itself, and several values as a list
+func oneOrList(values []string) interface{} {
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return values[0]
+	}
+	return values
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// the name of user, looked up in the known users, the page of block and then notion
+// the user id is returned if the user cannot be found
+func (s *Syncer) userName(block *notionapi.Block, userID string) string {
+	userID = notionapi.ToDashID(userID)
+	if name, ok := s.users[userID]; ok {
+		return name
+	}
+
+	var user *notionapi.User
+	if block.Page != nil {
+		user = block.Page.UserByID(userID)
+	}
+	if user == nil && s.client != nil && !s.offline {
+		resp, err := s.client.GetRecordValues([]notionapi.RecordRequest{{Table: notionapi.TableUser, ID: userID}})
+		if err != nil {
+			s.warn("Warning: cannot get user "+userID+".", err)
+		} else if len(resp.Results) == 1 {
+			user = resp.Results[0].User
+		}
+	}
+	if user == nil {
+		return userID
+	}
+
+	name, _ := user.RawJSON["name"].(string)
+	if name == "" {
+		name = strings.TrimSpace(user.GivenName + " " + user.FamilyName)
+	}
+	if name == "" {
+		name = firstNonEmpty(user.Email, userID)
+	}
+	if s.users == nil {
+		s.users = make(map[string]string)
+	}
+	s.users[userID] = name
+	return name
+}
+
+func (s *Syncer) userValue(block *notionapi.Block, userID string) interface{} {
+	if userID == "" {
+		return nil
+	}
+	return s.userName(block, userID)
+}
+
+// the names of users in person property
+func (s *Syncer) personValue(property interface{}, block *notionapi.Block) interface{} {
+	ids := propertyAttrs(property, notionapi.AttrUser)
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = s.userName(block, id)
+	}
+	return oneOrList(names)
+}
+
+// the urls of files in notion, they are rewritten by newPage
+func filesValue(property interface{}) interface{} {
+	return oneOrList(propertyAttrs(property, notionapi.AttrLink))
+}
+
+// the root block of related page, looked up in the cache, the page of block and then notion
+// nil is returned if the page cannot be found
+func (s *Syncer) relatedBlock(block *notionapi.Block, pageID string) *notionapi.Block {
+	pageID = notionapi.ToDashID(pageID)
+	if s.downloader != nil {
+		if page, err := s.readCachedPage(pageID); err == nil && page != nil {
+			return page.Root()
+		}
+	}
+	if block.Page != nil {
+		if related := block.Page.BlockByID(pageID); related != nil {
+			return related
+		}
+	}
+	if related, ok := s.blocks[pageID]; ok {
+		return related
+	}
+
+	var related *notionapi.Block
+	if s.client != nil && !s.offline {
+		resp, err := s.client.GetBlockRecords([]string{pageID})
+		if err != nil {
+			s.warn("Warning: cannot get related page "+pageID+".", err)
+		} else if len(resp.Results) == 1 {
+			related = resp.Results[0].Block
+		}
+	}
+	// remember the failed ones too, so they are requested only once
+	if s.blocks == nil {
+		s.blocks = make(map[string]*notionapi.Block)
+	}
+	s.blocks[pageID] = related
+	return related
+}
+
+// the related pages, each one has a title, and the pages of the blog also have a url
+func (s *Syncer) relationValue(property interface{}, block *notionapi.Block) interface{} {
+	ids := propertyAttrs(property, notionapi.AttrPage)
+	pages := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		id = notionapi.ToDashID(id)
+		page := map[string]interface{}{"title": id}
+		if related := s.relatedBlock(block, id); related != nil {
+			if title := propertyText(related.Properties["title"]); title != "" {
+				page["title"] = title
+			}
+		}
+		if _, ok := s.allPagesMap[id]; ok {
+			if url, err := s.getURL(id); err == nil && url != "" {
+				page["url"] = url
+			}
+		}
+		pages = append(pages, page)
+	}
+
+	switch len(pages) {
+	case 0:
+		return nil
+	case 1:
+		return pages[0]
+	}
+	return pages
+}
+
+// the files of page are downloaded like images, the values of their fields are rewritten to the urls in the blog
+func (s *Syncer) rewriteFiles(page *Page) {
+	rewrite := func(source string) string {
+		url, image := s.parseImage(source, page, page.ID)
+		if image != nil {
+			page.images = append(page.images, image)
+		}
+		return url
+	}
+
+	for _, field := range page.Fields {
+		if field.Type != notionapi.ColumnTypeFile {
+			continue
+		}
+		switch v := field.Value.(type) {
+		case string:
+			field.Value = rewrite(v)
+		case []string:
+			urls := make([]string, len(v))
+			for i, source := range v {
+				urls[i] = rewrite(source)
+			}
+			field.Value = urls
+		}
+	}
+}
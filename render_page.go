@@ -0,0 +1,121 @@
+package notionblog
+
+import (
+	"errors"
+	"log"
+
+	"github.com/kjk/notionapi"
+)
+
+// load the databases and the pages known by the last sync, so links and front matter are rendered like Sync does
+// the databases will be read from notion if tree.yml can not be used
+func (s *Syncer) loadKnownPages() error {
+	s.dbs = nil
+	s.topLevelPages = nil
+	s.topLevelPagesMap = make(map[string]*database)
+
+	tree, err := s.loadTree()
+	if err != nil {
+		if s.offline {
+			return err
+		}
+
+		log.Println("Cannot load databases from tree.yml, read them from notion.", err)
+		if err := s.connect(); err != nil {
+			return err
+		}
+		if err := s.fetchDatabaseInfo(); err != nil {
+			return err
+		}
+		if err := s.filterPublishedPages(); err != nil {
+			return err
+		}
+	}
+
+	s.allPages = make([]string, 0, len(s.topLevelPages))
+	s.allPagesMap = make(map[string]struct{}, len(s.topLevelPages))
+	for _, page := range s.topLevelPages {
+		s.allPages = append(s.allPages, page)
+		s.allPagesMap[page] = struct{}{}
+
+		if tree != nil {
+			for _, subPage := range tree.GetStringSlice("sub." + page) {
+				s.allPages = append(s.allPages, subPage)
+				s.allPagesMap[subPage] = struct{}{}
+			}
+		}
+	}
+
+	// the drafts are not pages of the blog, but they are still rendered with the front matter of their database
+	for _, db := range s.dbs {
+		for _, pageID := range db.allPageIDs {
+			if _, ok := s.topLevelPagesMap[pageID]; !ok {
+				s.topLevelPagesMap[pageID] = db
+			}
+		}
+	}
+	return nil
+}
+
+// prepare the state for rendering single pages
+func (s *Syncer) prepareRenderPage() error {
+	s.pages = make(map[string]*notionapi.Page)
+	s.initClient()
+	s.initUser()
+	if err := s.initDownloader(); err != nil {
+		return err
+	}
+
+	if err := s.loadKnownPages(); err != nil {
+		return err
+	}
+	return s.generateUrlMap()
+}
+
+// load a single page from cache or notion, and convert it to markdown
+func (s *Syncer) renderSinglePage(pageID string) ([]byte, []*Image, error) {
+	pageID = notionapi.ToDashID(notionapi.ExtractNoDashIDFromNotionURL(pageID))
+	if pageID == "" {
+		return nil, nil, errors.New("invalid page id or url")
+	}
+
+	var page *notionapi.Page
+	var err error
+	if s.offline {
+		page, err = s.readPageFromCache(pageID)
+	} else {
+		page, err = s.downloader.DownloadPage(pageID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.pages[pageID] = page
+
+	if _, ok := s.allPagesMap[pageID]; !ok {
+		s.urlMap[pageID] = s.getUrlForPage(page.Root())
+	}
+
+	return s.pageToMarkdown(page)
+}
+
+// RenderPage converts a single page to markdown the way Sync does, front matter included.
+// pageID can also be a notion url. The page is read from cache first.
+// The images are downloaded only if downloadImages is true, nothing else will be written.
+func (s *Syncer) RenderPage(pageID string, downloadImages bool) ([]byte, error) {
+	s.resetReport()
+
+	if err := s.prepareRenderPage(); err != nil {
+		return nil, s.finishReport(err)
+	}
+
+	data, images, err := s.renderSinglePage(pageID)
+	if err != nil {
+		return nil, s.finishReport(err)
+	}
+
+	if downloadImages && !s.offline {
+		s.downloadImages(images)
+	}
+
+	return data, s.finishReport(nil)
+}
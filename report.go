@@ -0,0 +1,235 @@
+package notionblog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PhaseTiming is the time used by a phase of sync
+type PhaseTiming struct {
+	Phase    string        `json:"phase"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+}
+
+// Failure is an error of a single page or image, which does not stop the sync
+type Failure struct {
+	PageID string `json:"page_id,omitempty"`
+	Image  string `json:"image,omitempty"`
+	Error  string `json:"error"`
+}
+
+// The status of a sync
+const (
+	StatusSuccess = "success"
+	// StatusPartial means some pages or images failed, or there are warnings
+	StatusPartial = "partial"
+	// StatusFailure means the sync stopped, or every page attempted in the sync failed
+	StatusFailure = "failure"
+)
+
+// Report describes what a sync has done
+type Report struct {
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	Seconds   float64   `json:"seconds"`
+
+	// Databases are the ids of processed databases
+	Databases []string `json:"databases"`
+	// DownloadedPages are the pages downloaded from notion
+	DownloadedPages []string `json:"downloaded_pages"`
+	// RenderedPages are the pages rendered again
+	RenderedPages []string `json:"rendered_pages"`
+	// UnpublishedPages are the pages skipped because they are not published
+	UnpublishedPages []string `json:"unpublished_pages"`
+	// ScheduledPages are the unpublished pages held back until their date
+	ScheduledPages []string `json:"scheduled_pages"`
+	// ExpiredPages are the pages unpublished because they are expired
+	ExpiredPages []string `json:"expired_pages"`
+	// DeletedFiles are the markdown files deleted
+	DeletedFiles []string `json:"deleted_files"`
+	// Images are the images fetched
+	Images   []string       `json:"images"`
+	Warnings []string       `json:"warnings"`
+	Failures []*Failure     `json:"failures"`
+	Timings  []*PhaseTiming `json:"timings"`
+	// Error is the error that stopped the sync
+	Error string `json:"error,omitempty"`
+
+	mu sync.Mutex
+}
+
+func newReport() *Report {
+	return &Report{
+		StartedAt:        time.Now(),
+		Databases:        []string{},
+		DownloadedPages:  []string{},
+		RenderedPages:    []string{},
+		UnpublishedPages: []string{},
+		ScheduledPages:   []string{},
+		ExpiredPages:     []string{},
+		DeletedFiles:     []string{},
+		Images:           []string{},
+		Warnings:         []string{},
+		Failures:         []*Failure{},
+		Timings:          []*PhaseTiming{},
+	}
+}
+
+// add appends values to a list of report, it's safe to be called concurrently
+func (r *Report) add(list *[]string, values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	*list = append(*list, values...)
+}
+
+// WriteJSON writes the report as JSON to w
+func (r *Report) WriteJSON(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Report returns the report of the last sync
+func (s *Syncer) Report() *Report {
+	return s.report
+}
+
+// start a new report
+func (s *Syncer) resetReport() {
+	s.report = newReport()
+}
+
+// end the report with the error that stopped the sync
+func (s *Syncer) finishReport(err error) error {
+	r := s.report
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Seconds = time.Since(r.StartedAt).Seconds()
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	switch {
+	case err != nil:
+		r.Status = StatusFailure
+	case r.allPagesFailed():
+		r.Status = StatusFailure
+	case len(r.Failures) != 0 || len(r.Warnings) != 0:
+		r.Status = StatusPartial
+	default:
+		r.Status = StatusSuccess
+	}
+
+	return err
+}
+
+// run f as a phase of sync and record its time
+func (s *Syncer) timePhase(phase string, f func() error) error {
+	start := time.Now()
+	err := f()
+	duration := time.Since(start)
+
+	s.report.mu.Lock()
+	s.report.Timings = append(s.report.Timings, &PhaseTiming{
+		Phase:    phase,
+		Duration: duration,
+		Seconds:  duration.Seconds(),
+	})
+	s.report.mu.Unlock()
+
+	return err
+}
+
+// fail logs the error of a page or image and records it in report
+func (s *Syncer) fail(pageID string, image string, err error) {
+	if image != "" {
+		log.Println("Warning: page", pageID, "image", image, "failed.", err)
+	} else {
+		log.Println("Warning: page", pageID, "failed.", err)
+	}
+	s.report.mu.Lock()
+	defer s.report.mu.Unlock()
+	s.report.Failures = append(s.report.Failures, &Failure{
+		PageID: pageID,
+		Image:  image,
+		Error:  err.Error(),
+	})
+}
+
+// failedPages returns the pages which failed in this sync
+func (r *Report) failedPages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pages := make([]string, 0, len(r.Failures))
+	for _, failure := range r.Failures {
+		if failure.PageID != "" {
+			pages = append(pages, failure.PageID)
+		}
+	}
+	return unique(pages)
+}
+
+// allPagesFailed reports whether some pages are downloaded or rendered in this sync, and all of them failed
+// a page with failed images is not failed, the lock must be held
+func (r *Report) allPagesFailed() bool {
+	failed := make(map[string]struct{}, len(r.Failures))
+	for _, failure := range r.Failures {
+		if failure.PageID != "" && failure.Image == "" {
+			failed[failure.PageID] = struct{}{}
+		}
+	}
+	if len(failed) == 0 {
+		return false
+	}
+	for _, list := range [][]string{r.DownloadedPages, r.RenderedPages} {
+		for _, pageID := range list {
+			if _, ok := failed[pageID]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// written reports whether any file of the blog is saved or deleted in this sync
+func (r *Report) written() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.DeletedFiles) != 0 {
+		return true
+	}
+	// a page failed without image is not saved
+	failed := make(map[string]struct{}, len(r.Failures))
+	for _, failure := range r.Failures {
+		if failure.PageID != "" && failure.Image == "" {
+			failed[failure.PageID] = struct{}{}
+		}
+	}
+	for _, pageID := range r.RenderedPages {
+		if _, ok := failed[pageID]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// warn logs the message and records it in report
+func (s *Syncer) warn(v ...interface{}) {
+	log.Println(v...)
+	if s.report != nil {
+		s.report.add(&s.report.Warnings, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+	}
+}
@@ -0,0 +1,29 @@
+package notionblog
+
+import (
+	"errors"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestFinishReport(t *testing.T) {
+	finish := func(downloaded []string, rendered []string, failed []string, err error) string {
+		s := &Syncer{report: newReport()}
+		s.report.DownloadedPages = downloaded
+		s.report.RenderedPages = rendered
+		for _, pageID := range failed {
+			s.fail(pageID, "", errors.New("broken"))
+		}
+		_ = s.finishReport(err)
+		return s.report.Status
+	}
+
+	assert.Equal(t, finish([]string{"a"}, []string{"a"}, nil, nil), StatusSuccess)
+	// an incremental sync with a failed download, the other pages are synced
+	assert.Equal(t, finish([]string{"a", "b"}, []string{"a", "b"}, []string{"c"}, nil), StatusPartial)
+	// every page attempted failed
+	assert.Equal(t, finish([]string{"a"}, nil, []string{"a", "b"}, nil), StatusFailure)
+	assert.Equal(t, finish(nil, []string{"a"}, []string{"a"}, nil), StatusFailure)
+	// the sync stopped
+	assert.Equal(t, finish([]string{"a"}, []string{"a"}, nil, errors.New("stopped")), StatusFailure)
+}
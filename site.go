@@ -0,0 +1,146 @@
+package notionblog
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/kjk/notionapi"
+)
+
+// Site is all published pages of the blog, used to generate the files of the whole site
+type Site struct {
+	Layout *Layout
+	// Posts are the pages in databases, in the order of database views
+	Posts []*Page
+	// Pages are the sub pages of posts
+	Pages []*Page
+	// URLs are the urls of pages by dashed page id
+	URLs map[string]string
+	// Databases are the posts of each database in database.post
+	Databases []*SiteDatabase
+	// Children are the sub pages found in each page by dashed page id, in the order they appear
+	// a sub page is only the child of the first page it's found in, like the tree of tree.yml
+	Children map[string][]string
+}
+
+// SiteDatabase is a database of posts
+type SiteDatabase struct {
+	// ID is the database id in config, pageID+viewID
+	ID string
+	// PageID is the dashed id of the page of database
+	PageID string
+	// Posts are in the order of database view
+	Posts []*Page
+}
+
+// SiteGenerator is implemented by the targets which generate files for the whole site, like sections and indexes
+type SiteGenerator interface {
+	// GenerateSite returns the content of files by their path, they are written after the pages
+	// a file with nil content is kept as is, the files generated by the last sync but not returned are deleted
+	GenerateSite(site *Site) (map[string][]byte, error)
+}
+
+// collect all cached pages of the blog
+func (s *Syncer) buildSite() *Site {
+	site := &Site{
+		Layout:   s.layout,
+		URLs:     s.urlMap,
+		Children: make(map[string][]string),
+	}
+
+	pages := make(map[string]*Page, len(s.allPages))
+	found := make(map[string]struct{}, len(s.allPages))
+	for _, pageID := range s.allPages {
+		page, err := s.readCachedPage(pageID)
+		if err != nil || page == nil {
+			continue
+		}
+		p := s.newPage(page.Root(), page.Root().Title)
+		pages[pageID] = p
+		if p.Post {
+			site.Posts = append(site.Posts, p)
+		} else {
+			site.Pages = append(site.Pages, p)
+		}
+
+		page.ForEachBlock(func(block *notionapi.Block) {
+			if !page.IsSubPage(block) {
+				return
+			}
+			subPageID := notionapi.ToDashID(block.ID)
+			if _, ok := s.allPagesMap[subPageID]; !ok {
+				return
+			}
+			if _, ok := s.topLevelPagesMap[subPageID]; ok {
+				return
+			}
+			if _, ok := found[subPageID]; ok {
+				return
+			}
+			found[subPageID] = struct{}{}
+			site.Children[pageID] = append(site.Children[pageID], subPageID)
+		})
+	}
+
+	for _, db := range s.dbs {
+		database := &SiteDatabase{ID: db.id(), PageID: notionapi.ToDashID(db.pageID)}
+		for _, pageID := range db.subpageIDs {
+			if page, ok := pages[pageID]; ok {
+				database.Posts = append(database.Posts, page)
+			}
+		}
+		site.Databases = append(site.Databases, database)
+	}
+
+	return site
+}
+
+// generate and save the site files of target, if it has any, and the feeds
+// the files are recorded in tree.yml, so the stale ones are deleted by the next sync
+func (s *Syncer) writeSite(plan *Plan) {
+	files := make(map[string][]byte)
+	if generator, ok := s.target.(SiteGenerator); ok {
+		generated, err := generator.GenerateSite(s.buildSite())
+		if err != nil {
+			s.warn("Warning: cannot generate site files.", err)
+			// keep the files of the last sync
+			for _, filename := range plan.siteFiles {
+				files[path.Join(s.rootDir, filename)] = nil
+			}
+		}
+		for filename, data := range generated {
+			files[filename] = data
+		}
+	}
+	for filename, data := range s.feeds() {
+		files[filename] = data
+	}
+
+	generated := 0
+	siteFiles := make([]string, 0, len(files))
+	for _, filename := range sortedKeys(files) {
+		if relFilename, err := filepath.Rel(s.rootDir, filename); err == nil {
+			siteFiles = append(siteFiles, filepath.ToSlash(relFilename))
+		}
+		if files[filename] == nil {
+			continue
+		}
+		generated++
+		if err := save(filename, files[filename]); err != nil {
+			s.warn("Warning: cannot save site file.", err)
+		}
+	}
+	log.Println("Generate", generated, "site files")
+
+	for _, filename := range findInBButNotInA(siteFiles, plan.siteFiles) {
+		filename = path.Join(s.rootDir, filename)
+		log.Println("Delete stale site file:", filename)
+		if err := os.Remove(filename); err == nil {
+			s.report.add(&s.report.DeletedFiles, filename)
+		}
+	}
+	plan.tree.Set("site_files", siteFiles)
+	s.saveTree(plan)
+}
@@ -0,0 +1,60 @@
+package notionblog
+
+import (
+	"encoding/xml"
+	"log"
+	"path"
+	"time"
+)
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// the sitemap of all published posts and their sub pages
+func sitemapXML(siteURL string, site *Site) ([]byte, error) {
+	urlSet := &sitemapURLSet{}
+	for _, pages := range [][]*Page{site.Posts, site.Pages} {
+		for _, page := range pages {
+			url, ok := site.URLs[page.ID]
+			if !ok {
+				continue
+			}
+			urlSet.URLs = append(urlSet.URLs, &sitemapURL{
+				Loc:     absoluteURL(siteURL, url),
+				LastMod: page.LastEditedTime.Format(time.RFC3339),
+			})
+		}
+	}
+	return marshalXML(urlSet)
+}
+
+// write sitemap.xml to the static dir
+func (s *Syncer) writeSitemap() {
+	if !s.config.GetBool("sitemap.enable") {
+		return
+	}
+	siteURL := s.config.GetString("url")
+	if siteURL == "" {
+		s.warn("Warning: url is not set, the sitemap is not generated.")
+		return
+	}
+
+	site := s.buildSite()
+	data, err := sitemapXML(siteURL, site)
+	if err != nil {
+		s.warn("Warning: cannot generate sitemap.", err)
+		return
+	}
+	if err := save(path.Join(s.layout.StaticDir, "sitemap.xml"), data); err != nil {
+		s.warn("Warning: cannot save sitemap.", err)
+		return
+	}
+	log.Println("Generate sitemap with", len(site.Posts)+len(site.Pages), "pages")
+}
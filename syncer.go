@@ -0,0 +1,255 @@
+package notionblog
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/caching_downloader"
+	"github.com/spf13/viper"
+)
+
+// Options is used to construct a Syncer
+type Options struct {
+	// RootDir is the root of the blog
+	RootDir string
+	// CacheDir is where downloaded pages are kept, default to the cache dir in the notion dir
+	CacheDir string
+	// Target overrides the target key of config.yml, the target must be registered by RegisterTarget
+	Target string
+	// DryRun makes the Syncer never change the disk, downloaded pages are kept in memory and Write will fail
+	DryRun bool
+	// Offline makes the Syncer never connect to notion, all pages are rendered from cache and the last tree.yml
+	Offline bool
+	// Check makes New keep the problems of dirs, config and target instead of failing, they are reported by Check
+	// The Syncer can only be used to Check then, it should be created with DryRun too
+	Check bool
+}
+
+// Syncer syncs the configured Notion databases into one blog.
+// All state of a sync is kept in the Syncer, so several Syncers can be used in one process.
+type Syncer struct {
+	dryRun  bool
+	offline bool
+	preview bool // links are rendered to the preview server
+
+	config   *viper.Viper // _notion/config.yml
+	versions *viper.Viper // _notion/version.yml
+
+	targetName string
+	target     Target
+	layout     *Layout
+
+	rootDir   string
+	notionDir string
+	cacheDir  string
+
+	client     *notionapi.Client
+	user       *notionapi.User
+	downloader *caching_downloader.Downloader
+	dbs        []*database
+	pages      map[string]*notionapi.Page  // pages read from cache, by dashed id
+	users      map[string]string           // the names of users, by dashed id, saved to tree.yml
+	blocks     map[string]*notionapi.Block // the root blocks of related pages out of the blog, by dashed id
+
+	// imageDownloader downloads the remote images into the same cache, the token is never sent to other sites
+	imageDownloader *caching_downloader.Downloader
+
+	topLevelPages    []string
+	topLevelPagesMap map[string]*database
+	updatedPages     []string
+	allPages         []string
+	allPagesMap      map[string]struct{}
+	urlMap           map[string]string
+	duplicateSlugs   map[string]struct{} // the posts whose slug is used by other posts, by dashed id
+
+	report *Report
+
+	problems []*Problem // the problems found by New in check mode
+}
+
+// New checks the blog dirs described by opts, creates the missing notion and cache dirs, loads the config file and the target
+func New(opts Options) (*Syncer, error) {
+	s := &Syncer{
+		dryRun:  opts.DryRun,
+		offline: opts.Offline,
+		report:  newReport(),
+	}
+
+	if opts.Check {
+		s.initForCheck(opts)
+		return s, nil
+	}
+
+	if err := s.initDirs(opts); err != nil {
+		return nil, err
+	}
+	if err := s.loadConfig(); err != nil {
+		return nil, err
+	}
+	if err := s.initTarget(opts); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// the steps of New, each problem is kept and the next steps are done as far as possible
+func (s *Syncer) initForCheck(opts Options) {
+	add := func(message, fix string) {
+		s.problems = append(s.problems, &Problem{Message: message, Fix: fix})
+	}
+
+	if err := s.initDirs(opts); err != nil {
+		add(err.Error(), "Pass the root of your blog by -root")
+		// the config is unknown, the defaults are checked
+		s.config = viper.New()
+		setDefaultConfig(s.config)
+		return
+	}
+	if err := s.loadConfig(); err != nil {
+		add(err.Error(), "Fix the syntax of config.yml")
+	}
+
+	if err := s.selectTarget(opts); err != nil {
+		add(err.Error(), "Set target in config.yml to a supported static site generator")
+		return
+	}
+	if err := s.initLayout(); err != nil {
+		add(err.Error(), "Check the root of your blog, or set target in config.yml to the static site generator it's built with")
+		// the schemas are still checked against the columns of target
+	}
+}
+
+// mustBeDir returns nil if dir exists
+func mustBeDir(dir string, name string) error {
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("the %s dir does not exist: %v", name, err)
+		}
+		return fmt.Errorf("can not open %s: %v", name, err)
+	}
+	return nil
+}
+
+// createDirIfNotExist creates dir if it does not exist
+func createDirIfNotExist(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			log.Println("Cannot find", dir, "dir, create one.")
+
+			err := os.Mkdir(dir, 0755)
+			if err != nil {
+				return fmt.Errorf("cannot create dir %s: %v", dir, err)
+			}
+		} else {
+			return fmt.Errorf("cannot open %s: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+// createDir creates dir if it does not exist, except in dry-run mode
+func (s *Syncer) createDir(dir string) error {
+	if s.dryRun {
+		return nil
+	}
+	return createDirIfNotExist(dir)
+}
+
+func (s *Syncer) initDirs(opts Options) error {
+	rootDir := opts.RootDir
+	if rootDir == "" {
+		rootDir = "."
+	}
+
+	rootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return fmt.Errorf("the root dir is invalid, maybe you should pass absolute path: %v", err)
+	}
+	log.Println("The root dir is", rootDir)
+	if err := mustBeDir(rootDir, "root"); err != nil {
+		return err
+	}
+	s.rootDir = rootDir
+
+	s.notionDir = findNotionDir(rootDir)
+	if err := s.createDir(s.notionDir); err != nil {
+		return err
+	}
+	log.Println("The notion dir is", s.notionDir)
+
+	if opts.CacheDir == "" {
+		s.cacheDir = path.Join(s.notionDir, "cache")
+	} else {
+		s.cacheDir, err = filepath.Abs(opts.CacheDir)
+		if err != nil {
+			return fmt.Errorf("the cache dir is invalid, maybe you should pass absolute path: %v", err)
+		}
+	}
+	if err := s.createDir(s.cacheDir); err != nil {
+		return err
+	}
+	log.Println("The cache dir is", s.cacheDir)
+
+	return nil
+}
+
+// select the target by opts or config, and find the blog dirs of it
+func (s *Syncer) initTarget(opts Options) error {
+	if err := s.selectTarget(opts); err != nil {
+		return err
+	}
+	return s.initLayout()
+}
+
+func (s *Syncer) selectTarget(opts Options) error {
+	name := opts.Target
+	if name == "" {
+		name = s.config.GetString("target")
+	}
+	target, err := getTarget(name, s.config)
+	if err != nil {
+		return err
+	}
+	s.targetName = name
+	s.target = target
+	log.Println("The target is", name)
+	return nil
+}
+
+func (s *Syncer) initLayout() error {
+	layout, err := s.target.Layout(s.rootDir)
+	if err != nil {
+		return err
+	}
+	s.layout = layout
+	log.Println("The posts dir is", s.layout.PostsDir)
+
+	return nil
+}
+
+// RootDir returns the absolute path of the blog root
+func (s *Syncer) RootDir() string {
+	return s.rootDir
+}
+
+// Sync runs all phases: Fetch, Plan, Render and Write
+// The report of the sync can be got by Report
+func (s *Syncer) Sync() error {
+	if err := s.Fetch(); err != nil {
+		return s.finishReport(err)
+	}
+	plan, err := s.Plan()
+	if err != nil {
+		return s.finishReport(err)
+	}
+	pages, err := s.Render(plan)
+	if err != nil {
+		return s.finishReport(err)
+	}
+	return s.finishReport(s.Write(plan, pages))
+}
@@ -0,0 +1,353 @@
+package notionblog
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/tomarkdown"
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// Target is the static site generator the blog is built with.
+// It decides where the markdown files are saved and the dialect they are written in.
+type Target interface {
+	// Layout returns the dirs of the blog in rootDir, it fails if rootDir is not a blog of the target
+	Layout(rootDir string) (*Layout, error)
+	// Columns returns the database columns the target knows, they are checked against the schema of databases
+	Columns() []*Column
+	// Filename returns the path of the markdown file of page, relative to Layout.PostsDir or Layout.PagesDir
+	Filename(page *Page) string
+	// URL returns the url of page if it has no url column
+	URL(page *Page) string
+	// Image returns where the image used by page is saved to and its url in markdown
+	// name is the unique path of the image, starts with a slash
+	Image(layout *Layout, page *Page, name string) (filename string, url string)
+	// FrontMatter renders the front matter of page, including the delimiters
+	FrontMatter(page *Page) (string, error)
+	// Link renders a link to another page of the blog, url is the url of the linked page
+	Link(page *Page, title string, url string) string
+	// Excerpt returns the mark which ends the excerpt of a post, the {% more %} paragraph is replaced with it
+	Excerpt() string
+	// Gist renders the embed of a gist
+	Gist(user string, id string) string
+}
+
+// BlockRenderer is implemented by the targets which render some blocks in their own way
+type BlockRenderer interface {
+	// RenderBlock renders block with c, returns false to use the default rendering
+	RenderBlock(c *tomarkdown.Converter, block *notionapi.Block) bool
+}
+
+// TextEscaper is implemented by the targets whose plain text needs escaping, e.g. the characters of jsx in mdx
+type TextEscaper interface {
+	// EscapeText escapes the text of a span, the text in inline code is never escaped
+	EscapeText(text string) string
+}
+
+// OutputConverter is implemented by the targets whose files are not markdown
+type OutputConverter interface {
+	// ConvertPage converts the rendered page to the content of its file
+	ConvertPage(page *Page, content *PageContent) ([]byte, error)
+}
+
+// BundleTarget is implemented by the targets which save every page to its own dir, together with its images
+// the whole dir is deleted when the page is deleted or moved
+type BundleTarget interface {
+	// BundleDir returns the dir of page, relative to Layout.PostsDir or Layout.PagesDir
+	BundleDir(page *Page) string
+}
+
+// ImageEmbedder is implemented by the targets which are read offline, e.g. books
+// the remote images are downloaded to the images dir too, instead of being linked
+type ImageEmbedder interface {
+	// EmbedImages returns true to download the remote images
+	EmbedImages() bool
+}
+
+// URLValidator is implemented by the targets which save pages by their url, some urls are used by the target itself
+// an invalid url column is ignored, the url given by the target is used instead
+type URLValidator interface {
+	// ValidateURL returns an error if the url column of page can't be used
+	ValidateURL(url string) error
+}
+
+// PageContent is a page rendered to markdown, given to OutputConverter
+type PageContent struct {
+	Markdown []byte
+	// Root is the root block of page, it must not be modified
+	Root *notionapi.Block
+	// Images are the images used in page
+	Images []*Image
+	// Links are the pages of the blog linked from page
+	Links []*PageLink
+}
+
+// PageLink is a link to another page of the blog
+type PageLink struct {
+	// ID is the dashed page id
+	ID    string
+	Title string
+	URL   string
+}
+
+// Layout is the dirs of a blog
+type Layout struct {
+	// PostsDir is where the pages in databases are saved
+	PostsDir string
+	// PagesDir is where the sub pages are saved
+	PagesDir string
+	// ImagesDir is where the images are downloaded to, empty if the images are kept with the pages
+	ImagesDir string
+	// ImagesURL is the url ImagesDir is served at
+	ImagesURL string
+	// StaticDir is where the files served at the root of the site are saved, e.g. the feeds
+	StaticDir string
+}
+
+// Column is a database column known by the target
+type Column struct {
+	Name string
+	// Types are the allowed notionapi.ColumnType*
+	Types []string
+	// Required columns must exist in every database
+	Required bool
+	// Reserved columns are used by the target itself, they are never put into the front matter
+	Reserved bool
+}
+
+// Page is a page of the blog given to the target
+type Page struct {
+	// ID is the dashed page id
+	ID string
+	// Post is true for the pages in databases, false for their sub pages
+	Post bool
+	// Position is the position of post in the view of its database, starts from 1
+	Position int
+	// CreatedTime and LastEditedTime are in the timezone of user
+	CreatedTime    time.Time
+	LastEditedTime time.Time
+	// Fields are the front matter of the page
+	Fields []*Field
+
+	images        []*Image // the files in front matter which need to be downloaded
+	duplicateSlug bool     // the slug is used by other posts too, so the page id is used instead
+}
+
+// Field is one item of the front matter
+type Field struct {
+	Name string
+	// Type is the notionapi.ColumnType* of the column, empty if the field is not from a column
+	Type string
+	// Value is a string, bool, int64 or float64 (numbers), []string, [][]string (the hierarchical categories),
+	// or map[string]interface{} and []map[string]interface{} (the related pages, with title and url)
+	Value interface{}
+}
+
+// whether the front matter has name, even if its value is empty
+func (p *Page) has(name string) bool {
+	for _, field := range p.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the value of front matter name, or nil if it doesn't exist
+func (p *Page) Get(name string) interface{} {
+	for _, field := range p.Fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+// GetString returns the value of front matter name if it's a string
+func (p *Page) GetString(name string) string {
+	v, _ := p.Get(name).(string)
+	return v
+}
+
+// NewTarget creates a target with the config.yml of the blog
+type NewTarget func(config *viper.Viper) (Target, error)
+
+var targets = map[string]NewTarget{}
+
+// RegisterTarget makes a target selectable by the target key of config.yml
+func RegisterTarget(name string, newTarget NewTarget) {
+	targets[name] = newTarget
+}
+
+// create the target selected in config
+func getTarget(name string, config *viper.Viper) (Target, error) {
+	newTarget, ok := targets[name]
+	if !ok {
+		names := make([]string, 0, len(targets))
+		for name := range targets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown target %s, must be one of: %s", name, strings.Join(names, ", "))
+	}
+	return newTarget(config)
+}
+
+// find the dir of config.yml, tree.yml and the cache
+// root/source/_notion is used by hexo blogs, root/_notion is used by other blogs
+func findNotionDir(rootDir string) string {
+	hexoDir := path.Join(rootDir, "source", "_notion")
+	otherDir := path.Join(rootDir, "_notion")
+	if _, err := os.Stat(hexoDir); err == nil {
+		return hexoDir
+	}
+	if _, err := os.Stat(otherDir); err == nil {
+		return otherDir
+	}
+	if _, err := os.Stat(path.Join(rootDir, "source")); err == nil {
+		return hexoDir
+	}
+	return otherDir
+}
+
+// build the page given to target from the root block of page
+// title is only used by the sub pages
+func (s *Syncer) newPage(block *notionapi.Block, title string) *Page {
+	pageID := notionapi.ToDashID(block.ID)
+
+	if db, ok := s.topLevelPagesMap[pageID]; ok {
+		page := &Page{
+			ID:             pageID,
+			Post:           true,
+			Position:       indexOf(db.subpageIDs, pageID) + 1,
+			CreatedTime:    s.milliTimeStampToTime(block.CreatedTime),
+			LastEditedTime: s.milliTimeStampToTime(block.LastEditedTime),
+			Fields:         db.frontMatter.fields(block),
+		}
+		_, page.duplicateSlug = s.duplicateSlugs[pageID]
+		// the url is given by target if the page has no url
+		if page.GetString("url") == "" {
+			page.Fields = append(page.Fields, &Field{Name: "url", Value: s.target.URL(page)})
+		}
+		sortFields(page.Fields, s.config.GetStringSlice("frontmatter.order"))
+		s.rewriteFiles(page)
+		return page
+	}
+
+	return &Page{
+		ID:             pageID,
+		CreatedTime:    s.milliTimeStampToTime(block.CreatedTime),
+		LastEditedTime: s.milliTimeStampToTime(block.LastEditedTime),
+		Fields: []*Field{
+			{Name: "title", Value: title},
+			{Name: "date", Value: s.milliTimeStampToISO8601String(block.CreatedTime)},
+			{Name: "updated", Value: s.milliTimeStampToISO8601String(block.CreatedTime)},
+		},
+	}
+}
+
+// sort fields by the names in order, the other fields follow them in the order of names
+func sortFields(fields []*Field, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		ri, iok := rank[fields[i].Name]
+		rj, jok := rank[fields[j].Name]
+		if iok && jok {
+			return ri < rj
+		}
+		if iok != jok {
+			return iok
+		}
+		return fields[i].Name < fields[j].Name
+	})
+}
+
+// get the page given to target by pageID, the front matter is empty if the page is not cached
+func (s *Syncer) getPage(pageID string) *Page {
+	pageID = notionapi.ToDashID(pageID)
+	page, err := s.readCachedPage(pageID)
+	if err != nil || page == nil {
+		_, post := s.topLevelPagesMap[pageID]
+		return &Page{ID: pageID, Post: post}
+	}
+	return s.newPage(page.Root(), page.Root().Title)
+}
+
+// parse the date string of front matter, the date without timezone is in location
+// dateOnly is true if there is no time in it
+func parseDate(date string, location *time.Location) (t time.Time, dateOnly bool, ok bool) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, false, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", date, location); err == nil {
+		return t, false, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", date, location); err == nil {
+		return t, true, true
+	}
+	return time.Time{}, false, false
+}
+
+// the slug column of post, used as the name of its file or dir
+// only the last element of the slug is used, so it never leaves the dir
+// the page id is used if there is no valid slug, or the slug is used by other posts
+func pageSlug(page *Page) string {
+	if page.Post && !page.duplicateSlug {
+		slug := path.Base(strings.ReplaceAll(page.GetString("slug"), "\\", "/"))
+		if slug != "." && slug != ".." && slug != "/" {
+			return slug
+		}
+	}
+	return notionapi.ToNoDashID(page.ID)
+}
+
+// the hierarchical categories in a flat list, for the generators without sub categories
+func flatCategories(categories [][]string) []string {
+	var values []string
+	for _, category := range categories {
+		values = append(values, category...)
+	}
+	return unique(values)
+}
+
+// render the front matter in yaml, with --- as the delimiters
+func yamlFrontMatter(fields yaml.MapSlice) (string, error) {
+	data, err := yaml.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(data) + "---\n", nil
+}
+
+// render the front matter in toml, with +++ as the delimiters
+// the keys are written in the order of fields, but the tables must follow all other keys in toml
+func tomlFrontMatter(fields yaml.MapSlice) (string, error) {
+	var keys, tables strings.Builder
+	for _, field := range fields {
+		tree, err := toml.TreeFromMap(map[string]interface{}{field.Key.(string): field.Value})
+		if err != nil {
+			return "", err
+		}
+		data, err := tree.ToTomlString()
+		if err != nil {
+			return "", err
+		}
+		switch field.Value.(type) {
+		case map[string]interface{}, []map[string]interface{}:
+			tables.WriteString(data)
+		default:
+			keys.WriteString(data)
+		}
+	}
+	return "+++\n" + keys.String() + tables.String() + "+++\n", nil
+}
@@ -0,0 +1,174 @@
+package notionblog
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kjk/notionapi"
+	"github.com/kjk/notionapi/tomarkdown"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// docusaurusTarget writes the pages as mdx docs: docs/<slug>.mdx and docs/pages/<id>.mdx
+type docusaurusTarget struct {
+	routeBasePath string // the url of docs
+}
+
+func newDocusaurusTarget(config *viper.Viper) (Target, error) {
+	return docusaurusTarget{
+		routeBasePath: strings.Trim(config.GetString("docusaurus.route_base_path"), "/"),
+	}, nil
+}
+
+func init() {
+	RegisterTarget("docusaurus", newDocusaurusTarget)
+}
+
+func (docusaurusTarget) Layout(rootDir string) (*Layout, error) {
+	if !fileExists(path.Join(rootDir, "docusaurus.config.js")) && !fileExists(path.Join(rootDir, "docusaurus.config.ts")) {
+		return nil, errors.New("cannot find docusaurus.config.js in the root dir, maybe it's not a docusaurus site")
+	}
+
+	docsDir := path.Join(rootDir, "docs")
+	return &Layout{
+		PostsDir:  docsDir,
+		PagesDir:  path.Join(docsDir, "pages"),
+		ImagesDir: path.Join(rootDir, "static", "img", "notion"),
+		ImagesURL: "/img/notion",
+		StaticDir: path.Join(rootDir, "static"),
+	}, nil
+}
+
+func (docusaurusTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "slug", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "sidebar_label", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "sidebar_position", Types: []string{notionapi.ColumnTypeNumber}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "id", Reserved: true},
+		{Name: "uuid", Reserved: true},
+	}
+}
+
+func (docusaurusTarget) slug(page *Page) string {
+	return pageSlug(page)
+}
+
+func (t docusaurusTarget) Filename(page *Page) string {
+	return t.slug(page) + ".mdx"
+}
+
+// the slug of docusaurus, relative to the route base path
+func (t docusaurusTarget) docSlug(page *Page) string {
+	if page.Post {
+		return "/" + t.slug(page)
+	}
+	return "/pages/" + t.slug(page)
+}
+
+func (t docusaurusTarget) URL(page *Page) string {
+	if t.routeBasePath == "" {
+		return t.docSlug(page)
+	}
+	return "/" + t.routeBasePath + t.docSlug(page)
+}
+
+func (docusaurusTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+func (t docusaurusTarget) FrontMatter(page *Page) (string, error) {
+	fields := yaml.MapSlice{
+		{Key: "id", Value: notionapi.ToNoDashID(page.ID)},
+	}
+	if page.Post && page.Get("sidebar_position") == nil {
+		fields = append(fields, yaml.MapItem{Key: "sidebar_position", Value: page.Position})
+	}
+
+	for _, field := range page.Fields {
+		name, value := field.Name, field.Value
+		switch name {
+		case "slug":
+			continue
+		case "url":
+			// the url column is the slug of docusaurus
+			if value == t.URL(page) {
+				value = t.docSlug(page)
+			}
+			name = "slug"
+		case "categories":
+			// docusaurus has no categories
+			continue
+		}
+		fields = append(fields, yaml.MapItem{Key: name, Value: value})
+	}
+	if !page.Post {
+		fields = append(fields, yaml.MapItem{Key: "slug", Value: t.docSlug(page)})
+	}
+
+	return yamlFrontMatter(fields)
+}
+
+func (docusaurusTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf("[%s](%s)", title, url)
+}
+
+func (docusaurusTarget) Excerpt() string {
+	return "{/* truncate */}"
+}
+
+// mdx can't run the script of gist, so the gist is a link
+func (docusaurusTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("[Gist %s/%s](https://gist.github.com/%s/%s)", user, id, user, id)
+}
+
+var mdxEscaper = strings.NewReplacer("{", "\\{", "}", "\\}", "<", "\\<")
+
+func (docusaurusTarget) EscapeText(text string) string {
+	return mdxEscaper.Replace(text)
+}
+
+// the admonition of callout by its icon
+func admonitionType(block *notionapi.Block) string {
+	icon, _ := block.PropAsString("format.page_icon")
+	switch icon {
+	case "💡":
+		return "tip"
+	case "ℹ️":
+		return "info"
+	case "⚠️", "❗", "❗️":
+		return "caution"
+	case "🚨", "⛔", "🔥":
+		return "danger"
+	default:
+		return "note"
+	}
+}
+
+func (docusaurusTarget) RenderBlock(c *tomarkdown.Converter, block *notionapi.Block) bool {
+	switch block.Type {
+	case notionapi.BlockCallout:
+		c.Printf(":::%s\n\n", admonitionType(block))
+		c.Printf("%s\n\n", c.GetInlineContent(block.InlineContent, true))
+		c.RenderChildren(block)
+		c.Printf(":::\n\n")
+	case notionapi.BlockToggle:
+		c.Printf("<details>\n<summary>%s</summary>\n\n", c.GetInlineContent(block.InlineContent, true))
+		c.RenderChildren(block)
+		c.Printf("\n</details>\n\n")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,371 @@
+package notionblog
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// the xml declaration is written before the template, html/template escapes it
+var epubChapterTemplate = template.Must(template.New("chapter").Parse(`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{.Language}}" lang="{{.Language}}">
+<head>
+<meta charset="utf-8"/>
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{.Content}}
+</body>
+</html>
+`))
+
+// epubTarget assembles the published pages of each database into an EPUB 3 book
+// the chapters are saved to <output>/chapters/<id>.xhtml, the images to <output>/images,
+// and the book of each database to <output>/<pageID>.epub
+type epubTarget struct {
+	output   string // the output dir, relative to the root dir
+	title    string // the title of books
+	author   string
+	language string
+
+	markdown goldmark.Markdown
+}
+
+func newEpubTarget(config *viper.Viper) (Target, error) {
+	return epubTarget{
+		output:   config.GetString("epub.output"),
+		title:    config.GetString("epub.title"),
+		author:   config.GetString("epub.author"),
+		language: config.GetString("epub.language"),
+		// the raw html in markdown is omitted, it's not always valid xhtml
+		markdown: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithRendererOptions(goldmarkhtml.WithXHTML()),
+		),
+	}, nil
+}
+
+func init() {
+	RegisterTarget("epub", newEpubTarget)
+}
+
+func (t epubTarget) Layout(rootDir string) (*Layout, error) {
+	outputDir := path.Join(rootDir, t.output)
+	return &Layout{
+		PostsDir:  path.Join(outputDir, "chapters"),
+		PagesDir:  path.Join(outputDir, "chapters"),
+		ImagesDir: path.Join(outputDir, "images"),
+		// relative to the chapters
+		ImagesURL: "../images",
+		StaticDir: outputDir,
+	}, nil
+}
+
+func (epubTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "uuid", Reserved: true},
+	}
+}
+
+// all chapters are in the same dir, so the filename is also the url
+func (epubTarget) Filename(page *Page) string {
+	return notionapi.ToNoDashID(page.ID) + ".xhtml"
+}
+
+func (t epubTarget) URL(page *Page) string {
+	return t.Filename(page)
+}
+
+func (epubTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+// the books are read offline, so the remote images are in books too
+func (epubTarget) EmbedImages() bool {
+	return true
+}
+
+func (epubTarget) FrontMatter(page *Page) (string, error) {
+	return "", nil
+}
+
+// links between chapters always use their files, the url column is for the web
+func (t epubTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf("[%s](%s)", title, t.Filename(page))
+}
+
+func (epubTarget) Excerpt() string {
+	return ""
+}
+
+// the scripts can't run in books, so the gist is a link
+func (epubTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("[Gist %s/%s](https://gist.github.com/%s/%s)", user, id, user, id)
+}
+
+func (t epubTarget) ConvertPage(page *Page, content *PageContent) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.markdown.Convert(content.Markdown, &b); err != nil {
+		return nil, err
+	}
+
+	var chapter bytes.Buffer
+	chapter.WriteString(xml.Header)
+	err := epubChapterTemplate.Execute(&chapter, map[string]interface{}{
+		"Language": t.language,
+		"Title":    page.GetString("title"),
+		"Content":  template.HTML(b.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chapter.Bytes(), nil
+}
+
+// epubChapter is a page in the table of contents
+type epubChapter struct {
+	page     *Page
+	children []*epubChapter
+}
+
+// the chapters of posts and their sub pages, a sub page is only used once
+// pages are the pages which can be chapters, by dashed page id
+func epubChapters(posts []*Page, site *Site, pages map[string]*Page, seen map[string]bool) []*epubChapter {
+	chapters := make([]*epubChapter, 0, len(posts))
+	for _, page := range posts {
+		if _, ok := pages[page.ID]; !ok || seen[page.ID] {
+			continue
+		}
+		seen[page.ID] = true
+
+		var children []*Page
+		for _, pageID := range site.Children[page.ID] {
+			if child, ok := pages[pageID]; ok {
+				children = append(children, child)
+			}
+		}
+		chapters = append(chapters, &epubChapter{
+			page:     page,
+			children: epubChapters(children, site, pages, seen),
+		})
+	}
+	return chapters
+}
+
+// the chapters in reading order
+func flatChapters(chapters []*epubChapter) []*epubChapter {
+	var result []*epubChapter
+	for _, chapter := range chapters {
+		result = append(result, chapter)
+		result = append(result, flatChapters(chapter.children)...)
+	}
+	return result
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeNavList(b *strings.Builder, chapters []*epubChapter) {
+	b.WriteString("<ol>\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(b, "<li><a href=\"chapters/%s.xhtml\">%s</a>", notionapi.ToNoDashID(chapter.page.ID), xmlEscape(chapter.page.GetString("title")))
+		if len(chapter.children) != 0 {
+			b.WriteString("\n")
+			writeNavList(b, chapter.children)
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ol>\n")
+}
+
+// the table of contents, which follows the tree of pages
+func (t epubTarget) nav(chapters []*epubChapter) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<meta charset="utf-8"/>
+<title>` + xmlEscape(t.title) + `</title>
+</head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>` + xmlEscape(t.title) + `</h1>
+`)
+	writeNavList(&b, chapters)
+	b.WriteString("</nav>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// the package document, listing all files of book and the reading order
+// images are the escaped urls relative to the images dir
+func (t epubTarget) opf(id string, chapters []*epubChapter, images []string) string {
+	var modified time.Time
+	for _, chapter := range chapters {
+		if chapter.page.LastEditedTime.After(modified) {
+			modified = chapter.page.LastEditedTime
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&b, "<dc:identifier id=\"book-id\">urn:uuid:%s</dc:identifier>\n", id)
+	fmt.Fprintf(&b, "<dc:title>%s</dc:title>\n", xmlEscape(t.title))
+	fmt.Fprintf(&b, "<dc:language>%s</dc:language>\n", xmlEscape(t.language))
+	if t.author != "" {
+		fmt.Fprintf(&b, "<dc:creator>%s</dc:creator>\n", xmlEscape(t.author))
+	}
+	fmt.Fprintf(&b, "<meta property=\"dcterms:modified\">%s</meta>\n", modified.UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString("</metadata>\n<manifest>\n")
+	b.WriteString("<item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	for _, chapter := range chapters {
+		noDashID := notionapi.ToNoDashID(chapter.page.ID)
+		fmt.Fprintf(&b, "<item id=\"c%s\" href=\"chapters/%s.xhtml\" media-type=\"application/xhtml+xml\"/>\n", noDashID, noDashID)
+	}
+	for i, image := range images {
+		mediaType := mime.TypeByExtension(strings.ToLower(path.Ext(image)))
+		if mediaType == "" {
+			mediaType = "application/octet-stream"
+		}
+		fmt.Fprintf(&b, "<item id=\"i%d\" href=\"images/%s\" media-type=\"%s\"/>\n", i, image, mediaType)
+	}
+	b.WriteString("</manifest>\n<spine>\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&b, "<itemref idref=\"c%s\"/>\n", notionapi.ToNoDashID(chapter.page.ID))
+	}
+	b.WriteString("</spine>\n</package>\n")
+	return b.String()
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+var epubImageRegexp = regexp.MustCompile(`src="\.\./images/([^"]+)"`)
+
+// the book of a database, chapters and images are read from the files saved before
+func (t epubTarget) book(database *SiteDatabase, site *Site, pages map[string]*Page) ([]byte, error) {
+	chapters := epubChapters(database.Posts, site, pages, make(map[string]bool))
+
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	// the mimetype must be the first file, and not compressed
+	f, err := w.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		"META-INF/container.xml": []byte(epubContainer),
+		"OEBPS/nav.xhtml":        []byte(t.nav(chapters)),
+	}
+	readingOrder := flatChapters(chapters)
+	var images []string
+	seenImages := make(map[string]bool)
+	for _, chapter := range readingOrder {
+		filename := t.Filename(chapter.page)
+		data, err := ioutil.ReadFile(path.Join(site.Layout.PostsDir, filename))
+		if err != nil {
+			return nil, err
+		}
+		files["OEBPS/chapters/"+filename] = data
+
+		for _, match := range epubImageRegexp.FindAllSubmatch(data, -1) {
+			// the src is escaped in the xhtml
+			href := string(match[1])
+			if seenImages[href] {
+				continue
+			}
+			seenImages[href] = true
+			image, err := url.PathUnescape(html.UnescapeString(href))
+			if err != nil {
+				continue
+			}
+			data, err := ioutil.ReadFile(path.Join(site.Layout.ImagesDir, image))
+			if err != nil {
+				// the image failed to download
+				continue
+			}
+			images = append(images, href)
+			files["OEBPS/images/"+image] = data
+		}
+	}
+	files["OEBPS/content.opf"] = []byte(t.opf(database.PageID, readingOrder, images))
+
+	for _, name := range sortedKeys(files) {
+		f, err := w.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// a book for each database
+func (t epubTarget) GenerateSite(site *Site) (map[string][]byte, error) {
+	// the pages failed and never saved are not chapters
+	pages := make(map[string]*Page, len(site.Posts)+len(site.Pages))
+	for _, list := range [][]*Page{site.Posts, site.Pages} {
+		for _, page := range list {
+			if fileExists(path.Join(site.Layout.PostsDir, t.Filename(page))) {
+				pages[page.ID] = page
+			}
+		}
+	}
+
+	files := make(map[string][]byte, len(site.Databases))
+	for _, database := range site.Databases {
+		data, err := t.book(database, site, pages)
+		if err != nil {
+			return nil, fmt.Errorf("cannot generate the book of database %s: %v", database.ID, err)
+		}
+		files[path.Join(site.Layout.StaticDir, notionapi.ToNoDashID(database.PageID)+".epub")] = data
+	}
+	return files, nil
+}
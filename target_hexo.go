@@ -0,0 +1,104 @@
+package notionblog
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// hexoTarget writes posts to source/_posts and sub pages to source/pages
+type hexoTarget struct{}
+
+func newHexoTarget(*viper.Viper) (Target, error) {
+	return hexoTarget{}, nil
+}
+
+func init() {
+	RegisterTarget("hexo", newHexoTarget)
+}
+
+func (hexoTarget) Layout(rootDir string) (*Layout, error) {
+	sourceDir := path.Join(rootDir, "source")
+	if err := mustBeDir(sourceDir, "root/source"); err != nil {
+		return nil, fmt.Errorf("%v, maybe it's not a hexo blog", err)
+	}
+
+	return &Layout{
+		PostsDir:  path.Join(sourceDir, "_posts"),
+		PagesDir:  path.Join(sourceDir, "pages"),
+		ImagesDir: path.Join(sourceDir, "images"),
+		ImagesURL: "/images",
+		StaticDir: sourceDir,
+	}, nil
+}
+
+func (hexoTarget) Columns() []*Column {
+	return []*Column{
+		// hexo
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "categories", Types: []string{notionapi.ColumnTypeSelect, notionapi.ColumnTypeMultiSelect}},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "comments", Types: []string{notionapi.ColumnTypeCheckbox}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// theme - next
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+
+		// reserve
+		{Name: "id", Reserved: true},
+		{Name: "uuid", Reserved: true},
+		{Name: "post_title", Reserved: true},
+		{Name: "permalink", Reserved: true},
+		{Name: "filename", Reserved: true},
+	}
+}
+
+func (hexoTarget) Filename(page *Page) string {
+	return notionapi.ToNoDashID(page.ID) + ".md"
+}
+
+func (hexoTarget) URL(page *Page) string {
+	noDashedPageID := notionapi.ToNoDashID(page.ID)
+
+	if page.Post {
+		return "/" + noDashedPageID
+	} else {
+		return "/pages/" + noDashedPageID + ".html"
+	}
+}
+
+func (hexoTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+// the values are quoted by yaml when needed, and the keys keep the order of fields
+func (hexoTarget) FrontMatter(page *Page) (string, error) {
+	fields := make(yaml.MapSlice, len(page.Fields))
+	for i, field := range page.Fields {
+		fields[i] = yaml.MapItem{Key: field.Name, Value: field.Value}
+	}
+	return yamlFrontMatter(fields)
+}
+
+func (hexoTarget) Link(page *Page, title string, url string) string {
+	if page.Post {
+		return "{% post_link " + notionapi.ToNoDashID(page.ID) + " %} "
+	}
+	return fmt.Sprintf("[%s](%s)", title, url)
+}
+
+func (hexoTarget) Excerpt() string {
+	return "<!-- more -->"
+}
+
+func (hexoTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("{%% gist %s %%}", id)
+}
@@ -0,0 +1,28 @@
+package notionblog
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestHexoFrontMatter(t *testing.T) {
+	page := &Page{Fields: []*Field{
+		{Name: "title", Value: "Go: [tips] #1 \"quoted\""},
+		{Name: "tags", Value: []string{"go", "c#"}},
+	}}
+	result, err := hexoTarget{}.FrontMatter(page)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, result, "---\ntitle: 'Go: [tips] #1 \"quoted\"'\ntags:\n- go\n- c#\n---\n")
+}
+
+func TestSortFields(t *testing.T) {
+	fields := []*Field{{Name: "uuid"}, {Name: "tags"}, {Name: "author"}, {Name: "title"}}
+	sortFields(fields, []string{"title", "date", "tags"})
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	assert.Equal(t, names, []string{"title", "tags", "author", "uuid"})
+}
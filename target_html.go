@@ -0,0 +1,380 @@
+package notionblog
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// the layouts of html target, each of them can be overridden by <name>.html in the templates dir
+var htmlTemplateNames = []string{"header", "footer", "post", "page", "index", "tag", "archive"}
+
+const htmlTemplates = `
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Title}}{{.Title}} - {{end}}{{.SiteTitle}}</title>
+<style>
+body { max-width: 760px; margin: 2em auto; padding: 0 1em; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.6; color: #24292e; }
+header nav a { margin-right: 1em; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+img { max-width: 100%; }
+blockquote { margin: 0; padding: 0 1em; color: #6a737d; border-left: .25em solid #dfe2e5; }
+.meta { color: #6a737d; font-size: .9em; }
+ul.posts { list-style: none; padding: 0; }
+</style>
+</head>
+<body>
+<header><nav><a href="/">{{.SiteTitle}}</a><a href="/archive.html">Archive</a></nav></header>
+<main>
+{{end}}
+
+{{define "footer"}}</main>
+</body>
+</html>
+{{end}}
+
+{{define "posts"}}<ul class="posts">
+{{range .}}<li><span class="meta">{{.Date.Format "2006-01-02"}}</span> <a href="{{.URL}}">{{.Title}}</a></li>
+{{end}}</ul>
+{{end}}
+
+{{define "post"}}{{template "header" .}}<article>
+<h1>{{.Page.Title}}</h1>
+<p class="meta">{{.Page.Date.Format "2006-01-02"}}{{range .Page.Tags}} <a href="{{.URL}}">#{{.Name}}</a>{{end}}</p>
+{{.Page.Content}}
+</article>
+{{template "footer" .}}{{end}}
+
+{{define "page"}}{{template "header" .}}<article>
+<h1>{{.Page.Title}}</h1>
+{{.Page.Content}}
+</article>
+{{template "footer" .}}{{end}}
+
+{{define "index"}}{{template "header" .}}{{template "posts" .Posts}}{{template "footer" .}}{{end}}
+
+{{define "tag"}}{{template "header" .}}<h1>#{{.Tag}}</h1>
+{{template "posts" .Posts}}{{template "footer" .}}{{end}}
+
+{{define "archive"}}{{template "header" .}}<h1>Archive</h1>
+{{range .Years}}<h2>{{.Year}}</h2>
+{{template "posts" .Posts}}{{end}}{{template "footer" .}}{{end}}
+`
+
+// htmlTarget renders the pages to html by templates, without any static site generator
+type htmlTarget struct {
+	title        string // the title of site
+	output       string // the output dir, relative to the root dir
+	templatesDir string // the dir of templates overriding the built-in ones, relative to the root dir
+
+	templates *template.Template
+	markdown  goldmark.Markdown
+}
+
+// htmlTag is a tag of post and the url of its page
+type htmlTag struct {
+	Name string
+	URL  string
+}
+
+// htmlPage is a page given to the templates
+type htmlPage struct {
+	Title       string
+	URL         string
+	Description string
+	Date        time.Time
+	Tags        []*htmlTag
+	// Fields are all front matter of page
+	Fields  map[string]interface{}
+	Content template.HTML
+}
+
+// htmlYear is the posts of a year in archive
+type htmlYear struct {
+	Year  int
+	Posts []*htmlPage
+}
+
+// htmlData is given to the templates, only the fields of the layout are filled
+type htmlData struct {
+	SiteTitle string
+	Title     string
+	Page      *htmlPage   // post, page
+	Posts     []*htmlPage // index, tag
+	Tag       string      // tag
+	Years     []*htmlYear // archive
+}
+
+func newHTMLTarget(config *viper.Viper) (Target, error) {
+	return &htmlTarget{
+		title:        config.GetString("html.title"),
+		output:       config.GetString("html.output"),
+		templatesDir: config.GetString("html.templates"),
+		markdown:     newMarkdownRenderer(),
+	}, nil
+}
+
+// the markdown renderer of the targets writing html, the html in markdown is kept
+func newMarkdownRenderer() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+}
+
+func init() {
+	RegisterTarget("html", newHTMLTarget)
+}
+
+// load the built-in templates, and override them by the files in dir
+func loadHTMLTemplates(dir string) (*template.Template, error) {
+	templates := template.Must(template.New("html").Parse(htmlTemplates))
+
+	for _, name := range htmlTemplateNames {
+		filename := path.Join(dir, name+".html")
+		if !fileExists(filename) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read template %s: %v", filename, err)
+		}
+		if _, err := templates.New(name).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("cannot parse template %s: %v", filename, err)
+		}
+	}
+
+	return templates, nil
+}
+
+// the templates are loaded here, because their dir is relative to the root dir
+func (t *htmlTarget) Layout(rootDir string) (*Layout, error) {
+	templates, err := loadHTMLTemplates(path.Join(rootDir, t.templatesDir))
+	if err != nil {
+		return nil, err
+	}
+	t.templates = templates
+
+	outputDir := path.Join(rootDir, t.output)
+	return &Layout{
+		PostsDir:  outputDir,
+		PagesDir:  outputDir,
+		ImagesDir: path.Join(outputDir, "images"),
+		ImagesURL: "/images",
+		StaticDir: outputDir,
+	}, nil
+}
+
+func (*htmlTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "slug", Types: []string{notionapi.ColumnTypeText}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "uuid", Reserved: true},
+	}
+}
+
+func (*htmlTarget) slug(page *Page) string {
+	return pageSlug(page)
+}
+
+func (t *htmlTarget) URL(page *Page) string {
+	if page.Post {
+		return "/posts/" + t.slug(page) + ".html"
+	}
+	return "/pages/" + t.slug(page) + ".html"
+}
+
+// the url of page, which may be set by the url column
+func (t *htmlTarget) pageURL(page *Page) string {
+	if url := page.GetString("url"); url != "" && t.ValidateURL(url) == nil {
+		return url
+	}
+	return t.URL(page)
+}
+
+// the file where url is served from the output dir
+func urlFilename(url string) string {
+	filename := strings.TrimPrefix(path.Clean("/"+url), "/")
+	if path.Ext(filename) != ".html" {
+		filename = path.Join(filename, "index.html")
+	}
+	return filename
+}
+
+// the file is where the url of page is served from the output dir
+func (t *htmlTarget) Filename(page *Page) string {
+	return urlFilename(t.pageURL(page))
+}
+
+// the url column must not overwrite the files generated by site, or the images
+func (*htmlTarget) ValidateURL(url string) error {
+	filename := urlFilename(url)
+	if filename == "index.html" || filename == "archive.html" {
+		return fmt.Errorf("%s is the url of %s", url, filename)
+	}
+	for _, dir := range []string{"tags", "images"} {
+		if strings.HasPrefix(filename, dir+"/") {
+			return fmt.Errorf("%s is in the %s dir", url, dir)
+		}
+	}
+	return nil
+}
+
+func (*htmlTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+// the front matter is given to the templates instead
+func (*htmlTarget) FrontMatter(page *Page) (string, error) {
+	return "", nil
+}
+
+func (*htmlTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf("[%s](%s)", title, url)
+}
+
+func (*htmlTarget) Excerpt() string {
+	return "<!-- more -->"
+}
+
+func (*htmlTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("<script src=\"https://gist.github.com/%s/%s.js\"></script>", user, id)
+}
+
+func tagURL(tag string) string {
+	return "/tags/" + url.PathEscape(tagSlug(tag)) + ".html"
+}
+
+// the name of the file of tag, it's escaped so every tag has its own file
+func tagSlug(tag string) string {
+	return url.PathEscape(tag)
+}
+
+// the page given to templates, without content
+func (t *htmlTarget) htmlPage(page *Page) *htmlPage {
+	p := &htmlPage{
+		Title:       page.GetString("title"),
+		URL:         t.pageURL(page),
+		Description: page.GetString("description"),
+		Date:        page.CreatedTime,
+		Fields:      make(map[string]interface{}, len(page.Fields)),
+	}
+	if date, _, ok := parseDate(page.GetString("date"), page.CreatedTime.Location()); ok {
+		p.Date = date
+	}
+	if tags, ok := page.Get("tags").([]string); ok {
+		for _, tag := range tags {
+			p.Tags = append(p.Tags, &htmlTag{Name: tag, URL: tagURL(tag)})
+		}
+	}
+	for _, field := range page.Fields {
+		p.Fields[field.Name] = field.Value
+	}
+	return p
+}
+
+func (t *htmlTarget) execute(name string, data *htmlData) ([]byte, error) {
+	data.SiteTitle = t.title
+	var b bytes.Buffer
+	if err := t.templates.ExecuteTemplate(&b, name, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (t *htmlTarget) ConvertPage(page *Page, content *PageContent) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.markdown.Convert(content.Markdown, &b); err != nil {
+		return nil, err
+	}
+
+	p := t.htmlPage(page)
+	p.Content = template.HTML(b.String())
+
+	layout := "page"
+	if page.Post {
+		layout = "post"
+	}
+	return t.execute(layout, &htmlData{Title: p.Title, Page: p})
+}
+
+// the index, archive and tag pages
+func (t *htmlTarget) GenerateSite(site *Site) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	outputDir := site.Layout.PostsDir
+
+	posts := make([]*htmlPage, len(site.Posts))
+	tags := make(map[string][]*htmlPage)
+	for i, page := range site.Posts {
+		posts[i] = t.htmlPage(page)
+		if url, ok := site.URLs[page.ID]; ok {
+			posts[i].URL = url
+		}
+		for _, tag := range posts[i].Tags {
+			tags[tag.Name] = append(tags[tag.Name], posts[i])
+		}
+	}
+	// the newest first
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Date.After(posts[j].Date)
+	})
+	for _, tagPosts := range tags {
+		sort.SliceStable(tagPosts, func(i, j int) bool {
+			return tagPosts[i].Date.After(tagPosts[j].Date)
+		})
+	}
+
+	data, err := t.execute("index", &htmlData{Posts: posts})
+	if err != nil {
+		return nil, err
+	}
+	files[path.Join(outputDir, "index.html")] = data
+
+	var years []*htmlYear
+	for _, post := range posts {
+		if len(years) == 0 || years[len(years)-1].Year != post.Date.Year() {
+			years = append(years, &htmlYear{Year: post.Date.Year()})
+		}
+		years[len(years)-1].Posts = append(years[len(years)-1].Posts, post)
+	}
+	data, err = t.execute("archive", &htmlData{Title: "Archive", Years: years})
+	if err != nil {
+		return nil, err
+	}
+	files[path.Join(outputDir, "archive.html")] = data
+
+	for tag, tagPosts := range tags {
+		data, err := t.execute("tag", &htmlData{Title: "#" + tag, Tag: tag, Posts: tagPosts})
+		if err != nil {
+			return nil, err
+		}
+		files[path.Join(outputDir, "tags", tagSlug(tag)+".html")] = data
+	}
+
+	return files, nil
+}
@@ -0,0 +1,23 @@
+package notionblog
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestHTMLFilename(t *testing.T) {
+	target := &htmlTarget{}
+	page := func(url string) *Page {
+		return &Page{ID: "11112222-aaaa-bbbb-cccc-ddddeeeeffff", Post: true, Fields: []*Field{{Name: "slug", Value: "hello"}, {Name: "url", Value: url}}}
+	}
+	assert.Equal(t, target.Filename(page("/about/")), "about/index.html")
+	assert.Equal(t, target.Filename(page("/about.html")), "about.html")
+	// the urls of the generated files are ignored
+	assert.Equal(t, target.Filename(page("/")), "posts/hello.html")
+	assert.Equal(t, target.Filename(page("/index.html")), "posts/hello.html")
+	assert.Equal(t, target.Filename(page("/tags/go/")), "posts/hello.html")
+
+	// every tag has its own file
+	assert.Equal(t, tagSlug("a/b") != tagSlug("a-b"), true)
+	assert.Equal(t, tagURL("a/b"), "/tags/a%252Fb.html")
+}
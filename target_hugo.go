@@ -0,0 +1,160 @@
+package notionblog
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// hugoTarget writes every page as a page bundle: content/<section>/<slug>/index.md with its images next to it
+type hugoTarget struct {
+	section     string // the section of posts
+	frontMatter string // toml or yaml
+}
+
+func newHugoTarget(config *viper.Viper) (Target, error) {
+	t := hugoTarget{
+		section:     config.GetString("hugo.section"),
+		frontMatter: config.GetString("hugo.front_matter"),
+	}
+	if t.frontMatter != "toml" && t.frontMatter != "yaml" {
+		return nil, fmt.Errorf("hugo.front_matter must be toml or yaml, got %s", t.frontMatter)
+	}
+	if t.section == "" || t.section == "pages" {
+		return nil, errors.New("hugo.section must not be empty or pages")
+	}
+	return t, nil
+}
+
+func init() {
+	RegisterTarget("hugo", newHugoTarget)
+}
+
+func (t hugoTarget) Layout(rootDir string) (*Layout, error) {
+	found := false
+	for _, name := range []string{"config.toml", "config.yaml", "config.yml", "config.json", "hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json", "config"} {
+		if fileExists(path.Join(rootDir, name)) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.New("cannot find the config file of hugo in the root dir, maybe it's not a hugo site")
+	}
+
+	contentDir := path.Join(rootDir, "content")
+	return &Layout{
+		PostsDir:  path.Join(contentDir, t.section),
+		PagesDir:  path.Join(contentDir, "pages"),
+		StaticDir: path.Join(rootDir, "static"),
+	}, nil
+}
+
+func (hugoTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "categories", Types: []string{notionapi.ColumnTypeSelect, notionapi.ColumnTypeMultiSelect}},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "lastmod", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "summary", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "slug", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "weight", Types: []string{notionapi.ColumnTypeNumber}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "uuid", Reserved: true},
+		{Name: "draft", Reserved: true},
+	}
+}
+
+// the name of bundle dir
+func (hugoTarget) slug(page *Page) string {
+	return pageSlug(page)
+}
+
+func (t hugoTarget) BundleDir(page *Page) string {
+	return t.slug(page)
+}
+
+func (t hugoTarget) Filename(page *Page) string {
+	return path.Join(t.slug(page), "index.md")
+}
+
+// the path of page relative to the content dir
+func (t hugoTarget) contentPath(page *Page) string {
+	if page.Post {
+		return "/" + t.section + "/" + t.slug(page)
+	}
+	return "/pages/" + t.slug(page)
+}
+
+func (t hugoTarget) URL(page *Page) string {
+	return t.contentPath(page) + "/"
+}
+
+func (t hugoTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	dir := layout.PagesDir
+	if page.Post {
+		dir = layout.PostsDir
+	}
+	// images are resources of the bundle, referenced relatively
+	return path.Join(dir, t.slug(page), "images", name), "images" + name
+}
+
+func (t hugoTarget) FrontMatter(page *Page) (string, error) {
+	fields := make(yaml.MapSlice, 0, len(page.Fields))
+	for _, field := range page.Fields {
+		name, value := field.Name, field.Value
+		switch name {
+		case "updated":
+			// an explicit lastmod column wins, updated is kept as is then
+			if !page.has("lastmod") {
+				name = "lastmod"
+			}
+		case "expires":
+			// only a date column is the expiry
+			if field.Type == notionapi.ColumnTypeDate {
+				name = "expiryDate"
+			}
+		case "url":
+			// the url overrides the permalinks of hugo, only write it if it's set in notion
+			if value == t.URL(page) {
+				continue
+			}
+		case "categories":
+			if categories, ok := value.([][]string); ok {
+				// hugo has no hierarchical taxonomies
+				value = flatCategories(categories)
+			}
+		}
+		fields = append(fields, yaml.MapItem{Key: name, Value: value})
+	}
+
+	if t.frontMatter == "yaml" {
+		return yamlFrontMatter(fields)
+	}
+
+	return tomlFrontMatter(fields)
+}
+
+func (t hugoTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf(`[%s]({{< ref "%s" >}})`, title, t.contentPath(page))
+}
+
+func (hugoTarget) Excerpt() string {
+	return "<!--more-->"
+}
+
+func (hugoTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("{{< gist %s %s >}}", user, id)
+}
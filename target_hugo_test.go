@@ -0,0 +1,45 @@
+package notionblog
+
+import (
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestHugoFrontMatter(t *testing.T) {
+	page := &Page{Post: true, Fields: []*Field{
+		{Name: "title", Value: "Hello"},
+		{Name: "series", Value: map[string]interface{}{"title": "Go"}},
+		{Name: "date", Value: "2021-03-04"},
+		{Name: "updated", Value: "2021-03-05"},
+	}}
+	result, err := hugoTarget{section: "posts", frontMatter: "toml"}.FrontMatter(page)
+	if err != nil {
+		t.Error(err)
+	}
+	// the keys keep their order, the tables follow them
+	assert.Equal(t, result, "+++\ntitle = \"Hello\"\ndate = \"2021-03-04\"\nlastmod = \"2021-03-05\"\n\n[series]\n  title = \"Go\"\n+++\n")
+
+	// the explicit lastmod column wins
+	page.Fields = append(page.Fields, &Field{Name: "lastmod", Value: "2021-03-06"})
+	result, err = hugoTarget{section: "posts", frontMatter: "yaml"}.FrontMatter(page)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, result, "---\ntitle: Hello\nseries:\n  title: Go\ndate: \"2021-03-04\"\nupdated: \"2021-03-05\"\nlastmod: \"2021-03-06\"\n---\n")
+}
+
+func TestPageSlug(t *testing.T) {
+	page := func(slug string) *Page {
+		return &Page{ID: "11112222-aaaa-bbbb-cccc-ddddeeeeffff", Post: true, Fields: []*Field{{Name: "slug", Value: slug}}}
+	}
+	assert.Equal(t, pageSlug(page("hello")), "hello")
+	assert.Equal(t, pageSlug(page("../../etc/passwd")), "passwd")
+	assert.Equal(t, pageSlug(page("a\\..\\b/")), "b")
+	assert.Equal(t, pageSlug(page("..")), "11112222aaaabbbbccccddddeeeeffff")
+	assert.Equal(t, pageSlug(page("")), "11112222aaaabbbbccccddddeeeeffff")
+
+	// the slug used by other posts
+	duplicate := page("hello")
+	duplicate.duplicateSlug = true
+	assert.Equal(t, pageSlug(duplicate), "11112222aaaabbbbccccddddeeeeffff")
+}
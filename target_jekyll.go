@@ -0,0 +1,156 @@
+package notionblog
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// jekyllTarget writes posts to _posts/YYYY-MM-DD-<slug>.md and sub pages to a collection
+type jekyllTarget struct {
+	collection string // the collection of sub pages, saved to _<collection>
+}
+
+func newJekyllTarget(config *viper.Viper) (Target, error) {
+	t := jekyllTarget{
+		collection: config.GetString("jekyll.collection"),
+	}
+	if t.collection == "" || t.collection == "posts" {
+		return nil, errors.New("jekyll.collection must not be empty or posts")
+	}
+	return t, nil
+}
+
+func init() {
+	RegisterTarget("jekyll", newJekyllTarget)
+}
+
+func (t jekyllTarget) Layout(rootDir string) (*Layout, error) {
+	if !fileExists(path.Join(rootDir, "_config.yml")) && !fileExists(path.Join(rootDir, "_config.toml")) {
+		return nil, errors.New("cannot find _config.yml in the root dir, maybe it's not a jekyll site")
+	}
+
+	return &Layout{
+		PostsDir:  path.Join(rootDir, "_posts"),
+		PagesDir:  path.Join(rootDir, "_"+t.collection),
+		ImagesDir: path.Join(rootDir, "assets", "images"),
+		ImagesURL: "/assets/images",
+		StaticDir: rootDir,
+	}, nil
+}
+
+func (jekyllTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "categories", Types: []string{notionapi.ColumnTypeSelect, notionapi.ColumnTypeMultiSelect}},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "slug", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "uuid", Reserved: true},
+		{Name: "permalink", Reserved: true},
+		{Name: "published", Reserved: true},
+	}
+}
+
+func (jekyllTarget) slug(page *Page) string {
+	return pageSlug(page)
+}
+
+// the YYYY-MM-DD prefix of post, read from the date column or the created time
+func (jekyllTarget) date(page *Page) string {
+	if date := page.GetString("date"); len(date) >= 10 {
+		if _, err := time.Parse("2006-01-02", date[:10]); err == nil {
+			return date[:10]
+		}
+	}
+	return page.CreatedTime.Format("2006-01-02")
+}
+
+// the name of post used by post_url
+func (t jekyllTarget) postName(page *Page) string {
+	return t.date(page) + "-" + t.slug(page)
+}
+
+func (t jekyllTarget) Filename(page *Page) string {
+	if page.Post {
+		return t.postName(page) + ".md"
+	}
+	return t.slug(page) + ".md"
+}
+
+// the url of the default permalink style: /:categories/:year/:month/:day/:title.html
+func (t jekyllTarget) URL(page *Page) string {
+	if !page.Post {
+		return "/" + t.collection + "/" + t.slug(page) + ".html"
+	}
+
+	var b strings.Builder
+	if categories, ok := page.Get("categories").([][]string); ok {
+		for _, category := range flatCategories(categories) {
+			b.WriteByte('/')
+			b.WriteString(strings.ToLower(category))
+		}
+	}
+	b.WriteByte('/')
+	b.WriteString(strings.Replace(t.date(page), "-", "/", -1))
+	b.WriteByte('/')
+	b.WriteString(t.slug(page))
+	b.WriteString(".html")
+	return b.String()
+}
+
+func (jekyllTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+func (t jekyllTarget) FrontMatter(page *Page) (string, error) {
+	fields := make(yaml.MapSlice, 0, len(page.Fields))
+	for _, field := range page.Fields {
+		name, value := field.Name, field.Value
+		switch name {
+		case "url":
+			// jekyll computes the url from permalink, only write it if it's set in notion
+			if value == t.URL(page) {
+				continue
+			}
+			name = "permalink"
+		case "categories":
+			if categories, ok := value.([][]string); ok {
+				// [a, b] is the sub category b of a in jekyll
+				value = flatCategories(categories)
+			}
+		}
+		fields = append(fields, yaml.MapItem{Key: name, Value: value})
+	}
+
+	return yamlFrontMatter(fields)
+}
+
+func (t jekyllTarget) Link(page *Page, title string, url string) string {
+	if page.Post {
+		return fmt.Sprintf("[%s]({%% post_url %s %%})", title, t.postName(page))
+	}
+	return fmt.Sprintf("[%s]({%% link _%s/%s %%})", title, t.collection, t.Filename(page))
+}
+
+func (jekyllTarget) Excerpt() string {
+	return "<!--more-->"
+}
+
+func (jekyllTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("{%% gist %s/%s %%}", user, id)
+}
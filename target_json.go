@@ -0,0 +1,282 @@
+package notionblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/spf13/viper"
+	"github.com/yuin/goldmark"
+)
+
+// jsonTarget exports every page as a json document for headless frontends
+// posts are saved to <output>/posts/<slug>.json, sub pages to <output>/pages/<id>.json
+// and all posts are listed in <output>/index.json
+type jsonTarget struct {
+	output    string // the output dir, relative to the root dir
+	assetsURL string // the url the assets dir is served at
+
+	markdown goldmark.Markdown
+}
+
+// jsonPage is the json document of a page, the content is omitted in index.json
+type jsonPage struct {
+	ID       string `json:"id"`
+	Post     bool   `json:"post"`
+	URL      string `json:"url"`
+	Position int    `json:"position,omitempty"`
+	// File is the path of the json document of page, relative to the output dir
+	File           string                 `json:"file"`
+	CreatedTime    time.Time              `json:"created_time"`
+	LastEditedTime time.Time              `json:"last_edited_time"`
+	FrontMatter    map[string]interface{} `json:"front_matter"`
+
+	Markdown string       `json:"markdown,omitempty"`
+	HTML     string       `json:"html,omitempty"`
+	Blocks   []*jsonBlock `json:"blocks,omitempty"`
+	Assets   []*jsonAsset `json:"assets,omitempty"`
+	Links    []*jsonLink  `json:"links,omitempty"`
+}
+
+// jsonBlock is a block of the page, with its children
+type jsonBlock struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Text is the rich text of block, the attrs are the format of notion, e.g. ["b"] or ["a", "https://..."]
+	Text     []*jsonSpan  `json:"text,omitempty"`
+	Checked  bool         `json:"checked,omitempty"`
+	Source   string       `json:"source,omitempty"`
+	Code     string       `json:"code,omitempty"`
+	Language string       `json:"language,omitempty"`
+	Children []*jsonBlock `json:"children,omitempty"`
+}
+
+// jsonSpan is a span of rich text
+type jsonSpan struct {
+	Text  string               `json:"text"`
+	Attrs []notionapi.TextAttr `json:"attrs,omitempty"`
+}
+
+// jsonAsset is an image used by the page
+type jsonAsset struct {
+	BlockID string `json:"block_id"`
+	URL     string `json:"url"`
+	Source  string `json:"source"`
+}
+
+// jsonLink is a page of the blog linked from the page
+type jsonLink struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// jsonIndex is the content of index.json
+type jsonIndex struct {
+	Posts []*jsonPage `json:"posts"`
+}
+
+func newJSONTarget(config *viper.Viper) (Target, error) {
+	return jsonTarget{
+		output:    config.GetString("json.output"),
+		assetsURL: config.GetString("json.assets_url"),
+		markdown:  newMarkdownRenderer(),
+	}, nil
+}
+
+func init() {
+	RegisterTarget("json", newJSONTarget)
+}
+
+func (t jsonTarget) Layout(rootDir string) (*Layout, error) {
+	outputDir := path.Join(rootDir, t.output)
+	return &Layout{
+		PostsDir:  path.Join(outputDir, "posts"),
+		PagesDir:  path.Join(outputDir, "pages"),
+		ImagesDir: path.Join(outputDir, "assets"),
+		ImagesURL: t.assetsURL,
+		StaticDir: outputDir,
+	}, nil
+}
+
+func (jsonTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "categories", Types: []string{notionapi.ColumnTypeSelect, notionapi.ColumnTypeMultiSelect}},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "slug", Types: []string{notionapi.ColumnTypeText}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "uuid", Reserved: true},
+	}
+}
+
+func (jsonTarget) slug(page *Page) string {
+	return pageSlug(page)
+}
+
+func (t jsonTarget) Filename(page *Page) string {
+	return t.slug(page) + ".json"
+}
+
+// the url of page in the frontend
+func (t jsonTarget) URL(page *Page) string {
+	if page.Post {
+		return "/posts/" + t.slug(page)
+	}
+	return "/pages/" + t.slug(page)
+}
+
+func (jsonTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+// the front matter is exported as typed values instead
+func (jsonTarget) FrontMatter(page *Page) (string, error) {
+	return "", nil
+}
+
+func (jsonTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf("[%s](%s)", title, url)
+}
+
+func (jsonTarget) Excerpt() string {
+	return "<!-- more -->"
+}
+
+func (jsonTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("<script src=\"https://gist.github.com/%s/%s.js\"></script>", user, id)
+}
+
+// convert the value of field to the type of its column
+// numbers become numbers and dates with time become RFC3339 times, dates without time are kept as YYYY-MM-DD
+func jsonValue(field *Field, location *time.Location) interface{} {
+	value, ok := field.Value.(string)
+	if !ok {
+		return field.Value
+	}
+
+	switch {
+	case field.Type == notionapi.ColumnTypeDate,
+		field.Type == notionapi.ColumnTypeCreatedTime,
+		field.Type == notionapi.ColumnTypeLastEditedTime,
+		field.Type == "" && (field.Name == "date" || field.Name == "updated"):
+		if t, dateOnly, ok := parseDate(value, location); ok && !dateOnly {
+			return t
+		}
+	}
+	return value
+}
+
+// the metadata of page, without content
+func (t jsonTarget) jsonPage(page *Page, url string) *jsonPage {
+	p := &jsonPage{
+		ID:             page.ID,
+		Post:           page.Post,
+		URL:            url,
+		Position:       page.Position,
+		File:           "pages/" + t.Filename(page),
+		CreatedTime:    page.CreatedTime,
+		LastEditedTime: page.LastEditedTime,
+		FrontMatter:    make(map[string]interface{}, len(page.Fields)),
+	}
+	if page.Post {
+		p.File = "posts/" + t.Filename(page)
+	}
+	for _, field := range page.Fields {
+		p.FrontMatter[field.Name] = jsonValue(field, page.CreatedTime.Location())
+	}
+	return p
+}
+
+// the tree of blocks, images use the url they are downloaded to
+func jsonBlocks(blocks []*notionapi.Block, images map[string]string) []*jsonBlock {
+	result := make([]*jsonBlock, 0, len(blocks))
+	for _, block := range blocks {
+		b := &jsonBlock{
+			ID:       block.ID,
+			Type:     block.Type,
+			Checked:  block.IsChecked,
+			Source:   block.Source,
+			Code:     block.Code,
+			Language: block.CodeLanguage,
+		}
+		for _, span := range block.InlineContent {
+			b.Text = append(b.Text, &jsonSpan{Text: span.Text, Attrs: span.Attrs})
+		}
+		if url, ok := images[block.ID]; ok {
+			b.Source = url
+		}
+		// the content of sub pages are in their own documents
+		if block.Type != notionapi.BlockPage {
+			b.Children = jsonBlocks(block.Content, images)
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+func (t jsonTarget) ConvertPage(page *Page, content *PageContent) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.markdown.Convert(content.Markdown, &b); err != nil {
+		return nil, err
+	}
+
+	url := page.GetString("url")
+	if url == "" {
+		url = t.URL(page)
+	}
+	p := t.jsonPage(page, url)
+	p.Markdown = string(content.Markdown)
+	p.HTML = b.String()
+
+	images := make(map[string]string, len(content.Images))
+	for _, image := range content.Images {
+		images[image.BlockID] = image.URL
+		p.Assets = append(p.Assets, &jsonAsset{BlockID: image.BlockID, URL: image.URL, Source: image.Source})
+	}
+	p.Blocks = jsonBlocks(content.Root.Content, images)
+	for _, link := range content.Links {
+		p.Links = append(p.Links, &jsonLink{ID: link.ID, Title: link.Title, URL: link.URL})
+	}
+
+	return marshalJSON(p)
+}
+
+// indent the json, and keep the html in it readable
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// index.json lists all posts in the order of database views
+func (t jsonTarget) GenerateSite(site *Site) (map[string][]byte, error) {
+	index := &jsonIndex{Posts: make([]*jsonPage, 0, len(site.Posts))}
+	for _, page := range site.Posts {
+		index.Posts = append(index.Posts, t.jsonPage(page, site.URLs[page.ID]))
+	}
+
+	data, err := marshalJSON(index)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		path.Join(path.Dir(site.Layout.PostsDir), "index.json"): data,
+	}, nil
+}
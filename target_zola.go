@@ -0,0 +1,254 @@
+package notionblog
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kjk/notionapi"
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/viper"
+)
+
+// zolaTarget writes posts to content/<section>/<slug>.md with toml front matter
+type zolaTarget struct {
+	section string // the section of posts
+	// the taxonomies declared by the site, from zola.taxonomies or filled by Layout from config.toml
+	taxonomies map[string]struct{}
+	configured bool // whether zola.taxonomies is set
+}
+
+func newZolaTarget(config *viper.Viper) (Target, error) {
+	t := zolaTarget{
+		section:    config.GetString("zola.section"),
+		taxonomies: make(map[string]struct{}),
+		configured: config.IsSet("zola.taxonomies"),
+	}
+	if t.section == "" || t.section == "pages" {
+		return nil, errors.New("zola.section must not be empty or pages")
+	}
+	for _, name := range config.GetStringSlice("zola.taxonomies") {
+		t.taxonomies[name] = struct{}{}
+	}
+	return t, nil
+}
+
+func init() {
+	RegisterTarget("zola", newZolaTarget)
+}
+
+func (t zolaTarget) Layout(rootDir string) (*Layout, error) {
+	configFilename := path.Join(rootDir, "config.toml")
+	if !fileExists(configFilename) {
+		return nil, errors.New("cannot find config.toml in the root dir, maybe it's not a zola site")
+	}
+	if !t.configured {
+		config, err := toml.LoadFile(configFilename)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config.toml: %v", err)
+		}
+		// taxonomies = [{name = "tags"}, ...]
+		if taxonomies, ok := config.Get("taxonomies").([]*toml.Tree); ok {
+			for _, taxonomy := range taxonomies {
+				if name, ok := taxonomy.Get("name").(string); ok {
+					t.taxonomies[name] = struct{}{}
+				}
+			}
+		}
+	}
+
+	contentDir := path.Join(rootDir, "content")
+	return &Layout{
+		PostsDir:  path.Join(contentDir, t.section),
+		PagesDir:  path.Join(contentDir, "pages"),
+		ImagesDir: path.Join(rootDir, "static", "images"),
+		ImagesURL: "/images",
+		StaticDir: path.Join(rootDir, "static"),
+	}, nil
+}
+
+func (zolaTarget) Columns() []*Column {
+	return []*Column{
+		{Name: "title", Types: []string{notionapi.ColumnTypeTitle}, Required: true},
+		{Name: "categories", Types: []string{notionapi.ColumnTypeSelect, notionapi.ColumnTypeMultiSelect}},
+		{Name: "tags", Types: []string{notionapi.ColumnTypeMultiSelect}},
+		{Name: "date", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeCreatedTime}},
+		{Name: "updated", Types: []string{notionapi.ColumnTypeDate, notionapi.ColumnTypeLastEditedTime}},
+		{Name: "description", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "slug", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "weight", Types: []string{notionapi.ColumnTypeNumber}},
+
+		// special
+		{Name: "url", Types: []string{notionapi.ColumnTypeText}},
+		{Name: "status", Types: []string{notionapi.ColumnTypeSelect}},
+
+		// reserve
+		{Name: "uuid", Reserved: true},
+		{Name: "path", Reserved: true},
+		{Name: "draft", Reserved: true},
+	}
+}
+
+// the front matter keys known by zola, other keys are put into [extra]
+var zolaKeys = map[string]struct{}{
+	"title":       {},
+	"description": {},
+	"date":        {},
+	"updated":     {},
+	"weight":      {},
+	"slug":        {},
+	"path":        {},
+	"aliases":     {},
+	"template":    {},
+}
+
+func (zolaTarget) slug(page *Page) string {
+	return pageSlug(page)
+}
+
+func (t zolaTarget) Filename(page *Page) string {
+	return t.slug(page) + ".md"
+}
+
+// the path of markdown file relative to the content dir, used by internal links
+func (t zolaTarget) contentPath(page *Page) string {
+	if page.Post {
+		return t.section + "/" + t.Filename(page)
+	}
+	return "pages/" + t.Filename(page)
+}
+
+func (t zolaTarget) URL(page *Page) string {
+	if page.Post {
+		return "/" + t.section + "/" + t.slug(page) + "/"
+	}
+	return "/pages/" + t.slug(page) + "/"
+}
+
+func (zolaTarget) Image(layout *Layout, page *Page, name string) (string, string) {
+	return path.Join(layout.ImagesDir, name), layout.ImagesURL + name
+}
+
+// convert the date string of front matter to a toml datetime, or a toml date if there is no time
+func tomlDate(value interface{}, location *time.Location) interface{} {
+	date, ok := value.(string)
+	if !ok {
+		return value
+	}
+	t, dateOnly, ok := parseDate(date, location)
+	if !ok {
+		return value
+	}
+	if dateOnly {
+		return toml.LocalDateOf(t)
+	}
+	return t
+}
+
+func (t zolaTarget) FrontMatter(page *Page) (string, error) {
+	tree, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return "", err
+	}
+
+	for _, field := range page.Fields {
+		name, value := field.Name, field.Value
+		if name == "url" {
+			// the path overrides the url of section, only write it if it's set in notion
+			if value == t.URL(page) {
+				continue
+			}
+			name = "path"
+		}
+		if name == "date" || name == "updated" {
+			value = tomlDate(value, page.CreatedTime.Location())
+		}
+		_, isTaxonomy := t.taxonomies[name]
+		switch v := value.(type) {
+		case [][]string:
+			// the terms of taxonomy are flat
+			value = flatCategories(v)
+		case string:
+			if isTaxonomy {
+				// the terms of taxonomy are a list
+				value = []string{v}
+			}
+		case map[string]interface{}, []map[string]interface{}:
+			// the related pages are tables, which must be built as trees to be set
+			wrapped, err := toml.TreeFromMap(map[string]interface{}{"value": v})
+			if err != nil {
+				return "", err
+			}
+			value = wrapped.Get("value")
+		}
+
+		if _, ok := zolaKeys[name]; ok {
+			tree.Set(name, value)
+		} else if isTaxonomy {
+			tree.SetPath([]string{"taxonomies", name}, value)
+		} else {
+			// including tags and categories if they are not declared, zola rejects the undeclared taxonomies
+			tree.SetPath([]string{"extra", name}, value)
+		}
+	}
+
+	data, err := tree.ToTomlString()
+	if err != nil {
+		return "", err
+	}
+	return "+++\n" + data + "+++\n", nil
+}
+
+func (t zolaTarget) Link(page *Page, title string, url string) string {
+	return fmt.Sprintf("[%s](@/%s)", title, t.contentPath(page))
+}
+
+func (zolaTarget) Excerpt() string {
+	return "<!-- more -->"
+}
+
+func (zolaTarget) Gist(user string, id string) string {
+	return fmt.Sprintf("<script src=\"https://gist.github.com/%s/%s.js\"></script>", user, id)
+}
+
+// the _index.md of sections, only created if they don't exist
+func (t zolaTarget) GenerateSite(site *Site) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	sections := []struct {
+		dir   string
+		title string
+		pages []*Page
+	}{
+		{site.Layout.PostsDir, strings.Title(t.section), site.Posts},
+		{site.Layout.PagesDir, "Pages", site.Pages},
+	}
+	for _, section := range sections {
+		filename := path.Join(section.dir, "_index.md")
+		if fileExists(filename) {
+			// it may be edited, so it's never written or deleted again
+			files[filename] = nil
+			continue
+		}
+		if len(section.pages) == 0 {
+			continue
+		}
+
+		tree, err := toml.TreeFromMap(map[string]interface{}{
+			"title":   section.title,
+			"sort_by": "date",
+		})
+		if err != nil {
+			return nil, err
+		}
+		data, err := tree.ToTomlString()
+		if err != nil {
+			return nil, err
+		}
+		files[filename] = []byte("+++\n" + data + "+++\n")
+	}
+
+	return files, nil
+}
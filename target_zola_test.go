@@ -0,0 +1,44 @@
+package notionblog
+
+import (
+	"github.com/kjk/notionapi"
+	"github.com/magiconair/properties/assert"
+	"testing"
+)
+
+func TestZolaFrontMatter(t *testing.T) {
+	page := &Page{Post: true, Fields: []*Field{
+		{Name: "title", Value: "Hello"},
+		{Name: "tags", Value: []string{"go"}},
+		{Name: "categories", Value: [][]string{{"dev", "web"}}},
+	}}
+	target := zolaTarget{section: "blog", taxonomies: map[string]struct{}{"tags": {}}}
+	result, err := target.FrontMatter(page)
+	if err != nil {
+		t.Error(err)
+	}
+	// categories is not declared by the site
+	assert.Equal(t, result, "+++\ntitle = \"Hello\"\n\n[extra]\n  categories = [\"dev\",\"web\"]\n\n[taxonomies]\n  tags = [\"go\"]\n+++\n")
+}
+
+func TestNumberFrontMatter(t *testing.T) {
+	f := &FrontMatter{s: &Syncer{}}
+	weight := f.getFrontMatterForType("weight", notionapi.ColumnTypeNumber, []interface{}{[]interface{}{"3"}}, nil)
+	rating := f.getFrontMatterForType("rating", notionapi.ColumnTypeNumber, []interface{}{[]interface{}{"4.5"}}, nil)
+	page := &Page{Post: true, Fields: []*Field{
+		{Name: "weight", Type: notionapi.ColumnTypeNumber, Value: weight},
+		{Name: "rating", Type: notionapi.ColumnTypeNumber, Value: rating},
+	}}
+
+	// numbers are never quoted
+	result, err := zolaTarget{section: "blog"}.FrontMatter(page)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, result, "+++\nweight = 3\n\n[extra]\n  rating = 4.5\n+++\n")
+	result, err = hexoTarget{}.FrontMatter(page)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, result, "---\nweight: 3\nrating: 4.5\n---\n")
+}
@@ -1,16 +1,15 @@
-package main
+package notionblog
 
 import (
 	"errors"
+	"strings"
+
 	"github.com/kjk/notionapi"
-	"log"
 )
 
-var urlMap map[string]string
-
 // get notion page url by pageID
 // will check if in the allPages
-func getURL(pageID string) (string, error) {
+func (s *Syncer) getURL(pageID string) (string, error) {
 	pageID = notionapi.ToDashID(pageID)
 	noDashedPageID := notionapi.ToNoDashID(pageID)
 
@@ -18,8 +17,12 @@ func getURL(pageID string) (string, error) {
 		return "", errors.New("pageID is invalid")
 	}
 
-	if _, ok := allPagesMap[pageID]; ok {
-		return getUrlByPageID(pageID), nil
+	if s.preview {
+		return previewURL(pageID), nil
+	}
+
+	if _, ok := s.allPagesMap[pageID]; ok {
+		return s.getUrlByPageID(pageID), nil
 	}
 
 	return "https://notion.so/" + noDashedPageID, nil
@@ -27,47 +30,93 @@ func getURL(pageID string) (string, error) {
 
 // get notion page url by pageID
 // please be sure the pageId is in allPages before call
-func getUrlByPageID(pageID string) string {
+func (s *Syncer) getUrlByPageID(pageID string) string {
 	pageID = notionapi.ToDashID(pageID)
-	return urlMap[pageID]
+	return s.urlMap[pageID]
 }
 
 // get notion page url by root block of page
 // can be call getUrlForPage(block) or getUrlForPage(page.Root())
 // please be sure the page is in database before call
-func getUrlForPage(block *notionapi.Block) string {
-	if db, ok := topLevelPagesMap[block.ID]; ok {
+func (s *Syncer) getUrlForPage(block *notionapi.Block) string {
+	if db, ok := s.topLevelPagesMap[block.ID]; ok {
 		if urlParam, ok := db.frontMatter.nameToId["url"]; ok {
 			if property, ok := block.Properties[urlParam.Id]; ok {
-				url := getStringLikeValue(property)
-				if url != "" {
+				location := s.milliTimeStampToTime(block.CreatedTime).Location()
+				url, _ := urlParam.Mapping.apply(s.getStringLikeValue(property), location).(string)
+				if url != "" && s.validURL(block, url) {
 					return url
 				}
 			}
 		}
 	}
 
-	return getDefaultUrlForPage(block)
+	return s.target.URL(s.newPage(block, block.Title))
 }
 
-func getDefaultUrlForPage(block *notionapi.Block) string {
-	noDashedPageID := notionapi.ToNoDashID(block.ID)
+// check the url column of page by the target
+func (s *Syncer) validURL(block *notionapi.Block, url string) bool {
+	if validator, ok := s.target.(URLValidator); ok {
+		if err := validator.ValidateURL(url); err != nil {
+			s.warn("Warning: the url of page", block.ID, "is ignored:", err)
+			return false
+		}
+	}
+	return true
+}
 
-	if _, ok := topLevelPagesMap[block.ID]; ok {
-		return "/" + noDashedPageID
-	} else {
-		return "/pages/" + noDashedPageID + ".html"
+func (s *Syncer) generateUrlMap() error {
+	// the files and urls depend on the slugs
+	if err := s.findDuplicateSlugs(); err != nil {
+		return err
 	}
+
+	s.urlMap = make(map[string]string, len(s.allPages))
+
+	for _, pageID := range s.allPages {
+		page, err := s.readCachedPage(pageID)
+		if err != nil {
+			return err
+		}
+		if page == nil {
+			// fail to download and never cached
+			continue
+		}
+		s.urlMap[pageID] = s.getUrlForPage(page.Root())
+	}
+	return nil
 }
 
-func generateUrlMap() {
-	urlMap = make(map[string]string, len(allPages))
+// find the posts sharing a slug, even in different databases, they would overwrite the files of each other
+// all of them use their page id instead
+func (s *Syncer) findDuplicateSlugs() error {
+	s.duplicateSlugs = make(map[string]struct{})
 
-	for _, pageID := range allPages {
-		page, err := downloader.ReadPageFromCache(pageID)
+	var slugs []string
+	pageIDs := make(map[string][]string)
+	for _, pageID := range s.topLevelPages {
+		page, err := s.readCachedPage(pageID)
 		if err != nil {
-			log.Fatal("Cannot read page from cache.", err)
+			return err
+		}
+		db, ok := s.topLevelPagesMap[notionapi.ToDashID(pageID)]
+		if page == nil || !ok {
+			continue
+		}
+		slug := pageSlug(&Page{ID: notionapi.ToDashID(pageID), Post: true, Fields: db.frontMatter.fields(page.Root())})
+		if _, ok := pageIDs[slug]; !ok {
+			slugs = append(slugs, slug)
+		}
+		pageIDs[slug] = append(pageIDs[slug], notionapi.ToDashID(pageID))
+	}
+
+	for _, slug := range slugs {
+		if len(pageIDs[slug]) > 1 {
+			s.warn("Warning: the slug "+slug+" is used by several posts, their page ids are used instead:", strings.Join(pageIDs[slug], ", "))
+			for _, pageID := range pageIDs[slug] {
+				s.duplicateSlugs[pageID] = struct{}{}
+			}
 		}
-		urlMap[pageID] = getUrlForPage(page.Root())
 	}
+	return nil
 }
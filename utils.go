@@ -1,7 +1,8 @@
-package main
+package notionblog
 
 import (
 	"github.com/kjk/notionapi"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -63,3 +64,36 @@ func findInBButNotInA(A []string, B []string) []string {
 	}
 	return result
 }
+
+// unique removes the duplicated items and keeps the order
+func unique(a []string) []string {
+	result := make([]string, 0, len(a))
+	m := make(map[string]struct{}, len(a))
+	for _, v := range a {
+		if _, ok := m[v]; !ok {
+			m[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// returns the index of v in a, or -1 if not found
+func indexOf(a []string, v string) int {
+	for i, item := range a {
+		if item == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// the keys of files in order
+func sortedKeys(files map[string][]byte) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package notionblog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WatchOptions is used to configure Watch
+type WatchOptions struct {
+	// Interval is the time between two checks
+	Interval time.Duration
+	// AfterSync will be called after each sync that wrote the blog, can be nil
+	AfterSync func() error
+}
+
+// Update syncs the blog again, only the pages whose version increased or which are (un)published will be rendered.
+// The connection and the database schemas of the last sync are reused, call Sync if they need to be reloaded.
+// It reports whether any file of the blog is written.
+func (s *Syncer) Update() (bool, error) {
+	if s.client == nil || s.offline {
+		// never connected
+		err := s.Sync()
+		return s.report.written(), err
+	}
+
+	s.resetReport()
+	if err := s.fetch(); err != nil {
+		return false, s.finishReport(err)
+	}
+	plan, err := s.Plan()
+	if err != nil {
+		return false, s.finishReport(err)
+	}
+	if len(plan.RenderPages) == 0 && len(plan.DeleteFiles) == 0 {
+		return false, s.finishReport(nil)
+	}
+	pages, err := s.Render(plan)
+	if err != nil {
+		return false, s.finishReport(err)
+	}
+	err = s.finishReport(s.Write(plan, pages))
+	// the pages which keep failing are rendered by every update, but nothing is written for them
+	return s.report.written(), err
+}
+
+// Watch updates the blog every interval until ctx is done.
+// Errors of a single update are logged and the next update will still run.
+func (s *Syncer) Watch(ctx context.Context, opts WatchOptions) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("the interval must be positive, got %v", opts.Interval)
+	}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		changed, err := s.Update()
+		if err != nil {
+			log.Println("Warning: fail to sync.", err)
+		} else if changed && opts.AfterSync != nil {
+			if err := opts.AfterSync(); err != nil {
+				log.Println("Warning: fail to run the after sync hook.", err)
+			}
+		} else if !changed {
+			log.Println("Nothing changed.")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}